@@ -0,0 +1,195 @@
+package calendar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+// Environment variables read by InitCalendarAPIFromEnv
+const (
+	// CredentialsEnv holds the service account key or the OAuth client JSON
+	CredentialsEnv = "GOOGLE_CALENDAR_CREDENTIALS"
+	// SubjectEnv is the user impersonated by the service account with domain-wide delegation
+	SubjectEnv = "GOOGLE_CALENDAR_SUBJECT"
+	// TokenEnv holds the OAuth token JSON used with an OAuth client
+	TokenEnv = "GOOGLE_CALENDAR_TOKEN"
+)
+
+const serviceAccountType = "service_account"
+
+// RequestTimeout limits a single Google Calendar request, a stalled request counts as a failure of the
+// circuit breaker
+var RequestTimeout = 30 * time.Second
+
+// FreeBusyScope only allows reading the free/busy information, the calendar package of the API client does not
+// define it yet
+const FreeBusyScope = "https://www.googleapis.com/auth/calendar.freebusy"
+
+// Scopes requested by the clients, events for the reservation events and free/busy for QueryFreeBusy. Stored OAuth
+// tokens granted before a scope was added have to be authorized again.
+var Scopes = []string{calendar.CalendarEventsScope, FreeBusyScope}
+
+// ErrNoToken is returned instead of prompting for an authorization code when no OAuth token is available
+var ErrNoToken = errors.New("no OAuth token available, authorize the application and store the token first")
+
+// ErrNoCredentials is returned when the credentials are not configured
+var ErrNoCredentials = errors.New("no Google Calendar credentials configured")
+
+// InitCalendarAPI initializes the client with an OAuth client JSON and a token file, refreshed tokens are
+// written back to the file
+func InitCalendarAPI(credentialsLocation string, tokenFilename string) error {
+	b, err := ioutil.ReadFile(credentialsLocation)
+	if err != nil {
+		log.Println(fmt.Sprintf("Error while reading file, filename: %s", credentialsLocation), err)
+
+		return err
+	}
+
+	config, err := OAuthConfigFromJSON(b)
+	if err != nil {
+		return err
+	}
+
+	return InitCalendarAPIWithTokenStore(config, NewFileTokenStore(tokenFilename))
+}
+
+// InitCalendarAPIWithServiceAccount initializes the client with a service account key, the subject is the
+// impersonated user when domain-wide delegation is used and can be empty otherwise
+func InitCalendarAPIWithServiceAccount(serviceAccountJSON []byte, subject string) error {
+	config, err := google.JWTConfigFromJSON(serviceAccountJSON, Scopes...)
+	if err != nil {
+		log.Println("Unable to parse service account key", err)
+
+		return err
+	}
+	config.Subject = subject
+
+	return InitCalendarAPIWithTokenSource(config.TokenSource(tokenContext()))
+}
+
+// InitCalendarAPIWithTokenSource initializes the client with any token source, requests time out after
+// RequestTimeout
+func InitCalendarAPIWithTokenSource(tokenSource oauth2.TokenSource) error {
+	httpClient := oauth2.NewClient(context.Background(), tokenSource)
+	httpClient.Timeout = RequestTimeout
+
+	service, err := calendar.NewService(context.Background(), option.WithHTTPClient(httpClient))
+	if err != nil {
+		log.Println(fmt.Sprintf("Unable to retrieve Calendar client"), err)
+
+		return err
+	}
+
+	calendarClient = service
+	return nil
+}
+
+// tokenContext makes the token requests time out after RequestTimeout as well
+func tokenContext() context.Context {
+	return context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Timeout: RequestTimeout})
+}
+
+// InitCalendarAPIWithSecrets initializes the client from the calendar secrets, the service account is
+// used when the credentials are a service account key, the OAuth token otherwise
+func InitCalendarAPIWithSecrets(secrets *props.CalendarSecrets) error {
+	return initCalendarAPI(secrets.Credentials, secrets.Subject, secrets.Token)
+}
+
+// InitCalendarAPIFromEnv initializes the client from the CredentialsEnv, SubjectEnv and TokenEnv variables
+func InitCalendarAPIFromEnv() error {
+	return initCalendarAPI([]byte(os.Getenv(CredentialsEnv)), os.Getenv(SubjectEnv), []byte(os.Getenv(TokenEnv)))
+}
+
+func initCalendarAPI(credentials []byte, subject string, token []byte) error {
+	if len(credentials) == 0 {
+		return ErrNoCredentials
+	}
+
+	var key struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(credentials, &key); err != nil {
+		log.Println("Unable to parse Google credentials", err)
+
+		return err
+	}
+	if key.Type == serviceAccountType {
+		return InitCalendarAPIWithServiceAccount(credentials, subject)
+	}
+
+	config, err := OAuthConfigFromJSON(credentials)
+	if err != nil {
+		return err
+	}
+
+	tok, err := TokenFromJSON(token)
+	if err != nil {
+		return err
+	}
+
+	return InitCalendarAPIWithTokenSource(config.TokenSource(tokenContext(), tok))
+}
+
+// OAuthConfigFromJSON parses the OAuth client JSON downloaded from the Google console
+func OAuthConfigFromJSON(credentials []byte) (*oauth2.Config, error) {
+	// If modifying these scopes, delete your previously saved token.json.
+	config, err := google.ConfigFromJSON(credentials, Scopes...)
+	if err != nil {
+		log.Println(fmt.Sprintf("Unable to parse client secret file to config"), err)
+
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// TokenFromJSON parses an OAuth token, ErrNoToken is returned for empty input
+func TokenFromJSON(data []byte) (*oauth2.Token, error) {
+	if len(data) == 0 {
+		return nil, ErrNoToken
+	}
+
+	tok := &oauth2.Token{}
+	if err := json.Unmarshal(data, tok); err != nil {
+		log.Println("Unable to parse OAuth token", err)
+
+		return nil, err
+	}
+	if tok.AccessToken == "" && tok.RefreshToken == "" {
+		return nil, ErrNoToken
+	}
+
+	return tok, nil
+}
+
+// AuthCodeURL returns the URL where the calendar owner authorizes offline access, used for obtaining the
+// first token outside of the services
+func AuthCodeURL(config *oauth2.Config, state string) string {
+	return config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+}
+
+// ExchangeAuthCode exchanges the authorization code returned by the consent page for a token
+func ExchangeAuthCode(config *oauth2.Config, authCode string) (*oauth2.Token, error) {
+	tok, err := config.Exchange(tokenContext(), authCode)
+	if err != nil {
+		log.Println("Unable to exchange authorization code", err)
+
+		return nil, err
+	}
+
+	return tok, nil
+}
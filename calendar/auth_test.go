@@ -0,0 +1,55 @@
+package calendar
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+const oauthClientJSON = `{"installed":{"client_id":"client","client_secret":"secret","auth_uri":"https://accounts.google.com/o/oauth2/auth","token_uri":"https://oauth2.googleapis.com/token","redirect_uris":["urn:ietf:wg:oauth:2.0:oob"]}}`
+
+func TestTokenFromJSON(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		data     string
+		expected error
+	}{
+		{
+			desc:     "empty",
+			data:     "",
+			expected: ErrNoToken,
+		},
+		{
+			desc:     "without tokens",
+			data:     `{"token_type":"Bearer"}`,
+			expected: ErrNoToken,
+		},
+		{
+			desc:     "refresh token only",
+			data:     `{"refresh_token":"refresh"}`,
+			expected: nil,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := TokenFromJSON([]byte(tC.data))
+			if errors.Cause(err) != tC.expected {
+				t.Errorf("expected %v, got %v", tC.expected, err)
+			}
+		})
+	}
+}
+
+func TestInitCalendarAPIWithoutToken(t *testing.T) {
+	if err := initCalendarAPI(nil, "", nil); err != ErrNoCredentials {
+		t.Errorf("expected ErrNoCredentials, got %v", err)
+	}
+
+	if err := initCalendarAPI([]byte(oauthClientJSON), "", nil); errors.Cause(err) != ErrNoToken {
+		t.Errorf("expected ErrNoToken instead of prompting, got %v", err)
+	}
+
+	if err := initCalendarAPI([]byte(oauthClientJSON), "", []byte(`{"refresh_token":"refresh"}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package calendar
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	cal "google.golang.org/api/calendar/v3"
+
+	"github.com/sylank/lavender-commons-go/dynamo"
+)
+
+// CancelledPrefix is put in front of the summary of the cancelled events
+const CancelledPrefix = "[CANCELLED] "
+
+// Private extended properties of the cancelled events
+const (
+	CancelledProperty          = "cancelled"
+	CancellationReasonProperty = "cancellationReason"
+	CancellationTypeProperty   = "cancellationType"
+	CancelledAtProperty        = "cancelledAt"
+	OriginalSummaryProperty    = "originalSummary"
+)
+
+// Google limits the values of the extended properties to 1024 characters
+const maxPropertyLength = 1024
+
+const (
+	transparent = "transparent"
+	opaque      = "opaque"
+)
+
+// ErrEventNotCancelled is returned when restoring an event which was not cancelled
+var ErrEventNotCancelled = errors.New("event is not cancelled")
+
+// IsMarkedCancelled returns true for events cancelled by CancelEventByID, unlike IsCancelled these events are
+// still on the calendar
+func IsMarkedCancelled(event *cal.Event) bool {
+	if event.ExtendedProperties == nil {
+		return false
+	}
+
+	cancelled, _ := strconv.ParseBool(event.ExtendedProperties.Private[CancelledProperty])
+	return cancelled
+}
+
+// CancelEventByID keeps the event on the calendar but marks it cancelled: the summary gets the CancelledPrefix,
+// the event no longer blocks the time and the reason is stored in the private properties. DeleteEventByID removes
+// the event for good.
+func CancelEventByID(calendarID string, eventID string, reason string) (*cal.Event, error) {
+	return cancelEvent(calendarID, eventID, reason, "")
+}
+
+// RestoreEventByID undoes CancelEventByID
+func RestoreEventByID(calendarID string, eventID string) (*cal.Event, error) {
+	event, err := GetEvent(calendarID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if !IsMarkedCancelled(event) {
+		return nil, errors.Wrap(ErrEventNotCancelled, eventID)
+	}
+
+	private := event.ExtendedProperties.Private
+	event.Summary = private[OriginalSummaryProperty]
+	event.Transparency = opaque
+	for _, name := range []string{CancelledProperty, CancellationReasonProperty, CancellationTypeProperty, CancelledAtProperty, OriginalSummaryProperty} {
+		delete(private, name)
+	}
+
+	restored, err := updateEvent(calendarID, event)
+	if err != nil {
+		log.Println("Unable to restore event with event id: "+eventID, err)
+		return nil, err
+	}
+
+	log.Println("Event restored with event id: " + eventID)
+	return restored, nil
+}
+
+// CancelReservationEvent cancels the event of the deleted reservation, the message of the deletion is the reason
+func CancelReservationEvent(apartmentCode string, deletion *dynamo.DeletionInsertModel) (*cal.Event, error) {
+	calendarID, err := CalendarIDForApartment(apartmentCode)
+	if err != nil {
+		log.Println("Unable to cancel event", err)
+		return nil, err
+	}
+
+	event, err := FindReservationEvent(calendarID, deletion.ReservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return cancelEvent(calendarID, event.Id, deletion.Message, deletion.Type)
+}
+
+// RestoreReservationEvent restores the cancelled event of the reservation
+func RestoreReservationEvent(apartmentCode string, reservationID string) (*cal.Event, error) {
+	calendarID, err := CalendarIDForApartment(apartmentCode)
+	if err != nil {
+		log.Println("Unable to restore event", err)
+		return nil, err
+	}
+
+	event, err := FindReservationEvent(calendarID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return RestoreEventByID(calendarID, event.Id)
+}
+
+func cancelEvent(calendarID string, eventID string, reason string, cancellationType string) (*cal.Event, error) {
+	event, err := GetEvent(calendarID, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.ExtendedProperties == nil {
+		event.ExtendedProperties = &cal.EventExtendedProperties{}
+	}
+	if event.ExtendedProperties.Private == nil {
+		event.ExtendedProperties.Private = map[string]string{}
+	}
+	private := event.ExtendedProperties.Private
+
+	if !IsMarkedCancelled(event) {
+		private[OriginalSummaryProperty] = truncate(event.Summary)
+		event.Summary = CancelledPrefix + event.Summary
+	}
+	private[CancelledProperty] = strconv.FormatBool(true)
+	private[CancelledAtProperty] = time.Now().UTC().Format(time.RFC3339)
+	private[CancellationReasonProperty] = truncate(reason)
+	if cancellationType != "" {
+		private[CancellationTypeProperty] = cancellationType
+	}
+	event.Transparency = transparent
+
+	cancelled, err := updateEvent(calendarID, event)
+	if err != nil {
+		log.Println("Unable to cancel event with event id: "+eventID, err)
+		return nil, err
+	}
+
+	log.Println("Event cancelled with event id: " + eventID)
+	return cancelled, nil
+}
+
+// keepCancelled carries the cancellation of the existing event over to its updated version
+func keepCancelled(event *cal.Event, existing *cal.Event) {
+	private := map[string]string{}
+	for name, value := range existing.ExtendedProperties.Private {
+		private[name] = value
+	}
+	for name, value := range event.ExtendedProperties.Private {
+		private[name] = value
+	}
+	private[OriginalSummaryProperty] = truncate(event.Summary)
+
+	event.Summary = CancelledPrefix + event.Summary
+	event.Transparency = transparent
+	event.ExtendedProperties.Private = private
+}
+
+func updateEvent(calendarID string, event *cal.Event) (*cal.Event, error) {
+	return doEvent(calendarClient.Events.Update(calendarID, event.Id, event).Do)
+}
+
+func truncate(value string) string {
+	if len(value) <= maxPropertyLength {
+		return value
+	}
+
+	cut := maxPropertyLength
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+
+	return strings.TrimSpace(value[:cut])
+}
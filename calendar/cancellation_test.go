@@ -0,0 +1,172 @@
+package calendar
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	cal "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+
+	"github.com/sylank/lavender-commons-go/dynamo"
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+func newTestEventServer(t *testing.T, event *cal.Event) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/events"):
+			json.NewEncoder(w).Encode(&cal.Events{Items: []*cal.Event{event}})
+		case r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(event)
+		case r.Method == http.MethodPatch:
+			json.NewDecoder(r.Body).Decode(event)
+			json.NewEncoder(w).Encode(event)
+		case r.Method == http.MethodPut:
+			updated := &cal.Event{}
+			json.NewDecoder(r.Body).Decode(updated)
+			*event = *updated
+			json.NewEncoder(w).Encode(event)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+
+	service, err := cal.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	calendarClient = service
+
+	return server
+}
+
+func TestCancelAndRestoreEvent(t *testing.T) {
+	event := reservationEventWithDates(&cal.EventDateTime{Date: "2020-10-01"}, &cal.EventDateTime{Date: "2020-10-04"})
+	event.Summary = "John Doe - A1"
+	event.Transparency = "opaque"
+	server := newTestEventServer(t, event)
+	defer server.Close()
+
+	SetCalendarProperties(&props.CalendarProperties{
+		CalendarInfo: map[string]props.CalendarInfo{
+			"A1": {CalendarID: "a1@group.calendar.google.com"},
+		},
+	})
+	defer SetCalendarProperties(nil)
+
+	if _, err := RestoreEventByID("a1@group.calendar.google.com", "e1"); errors.Cause(err) != ErrEventNotCancelled {
+		t.Errorf("expected ErrEventNotCancelled, got %v", err)
+	}
+
+	cancelled, err := CancelReservationEvent("A1", &dynamo.DeletionInsertModel{
+		ReservationID: "reservation-1",
+		Type:          "GUEST_CANCELLED",
+		Message:       "Flight cancelled",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	private := cancelled.ExtendedProperties.Private
+	if !IsMarkedCancelled(cancelled) || cancelled.Summary != "[CANCELLED] John Doe - A1" || cancelled.Transparency != "transparent" {
+		t.Errorf("unexpected cancelled event: %+v", cancelled)
+	}
+	if private[CancellationReasonProperty] != "Flight cancelled" || private[CancellationTypeProperty] != "GUEST_CANCELLED" ||
+		private[OriginalSummaryProperty] != "John Doe - A1" || private[ReservationIDProperty] != "reservation-1" {
+		t.Errorf("unexpected private properties: %v", private)
+	}
+
+	again, err := CancelEventByID("a1@group.calendar.google.com", "e1", "duplicate")
+	if err != nil || again.Summary != "[CANCELLED] John Doe - A1" {
+		t.Errorf("cancelling twice should not prefix twice: %s, %v", again.Summary, err)
+	}
+
+	restored, err := RestoreReservationEvent("A1", "reservation-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsMarkedCancelled(restored) || restored.Summary != "John Doe - A1" || restored.Transparency != "opaque" {
+		t.Errorf("unexpected restored event: %+v", restored)
+	}
+	if _, ok := restored.ExtendedProperties.Private[CancellationReasonProperty]; ok {
+		t.Errorf("cancellation properties should be removed: %v", restored.ExtendedProperties.Private)
+	}
+}
+
+func TestUpdateCancelledReservationEvent(t *testing.T) {
+	event := reservationEventWithDates(&cal.EventDateTime{Date: "2020-10-01"}, &cal.EventDateTime{Date: "2020-10-04"})
+	event.Summary = "John Doe - A1"
+	server := newTestEventServer(t, event)
+	defer server.Close()
+
+	SetCalendarProperties(&props.CalendarProperties{
+		CalendarInfo: map[string]props.CalendarInfo{
+			"A1": {CalendarID: "a1@group.calendar.google.com"},
+		},
+	})
+	defer SetCalendarProperties(nil)
+
+	if _, err := CancelEventByID("a1@group.calendar.google.com", "e1", "Flight cancelled"); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := UpdateReservationEvent(&dynamo.ReservationModel{
+		ReservationID: "reservation-1",
+		FromDate:      "2020-10-02",
+		ToDate:        "2020-10-05",
+		ApartmentCode: "A1",
+	}, "Jane Doe")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsMarkedCancelled(updated) || updated.Summary != "[CANCELLED] Jane Doe - A1" || updated.Transparency != "transparent" {
+		t.Errorf("updated event should stay cancelled: %+v", updated)
+	}
+	if updated.Start.Date != "2020-10-02" || updated.ExtendedProperties.Private[CancellationReasonProperty] != "Flight cancelled" ||
+		updated.ExtendedProperties.Private[OriginalSummaryProperty] != "Jane Doe - A1" {
+		t.Errorf("unexpected updated event: %+v, %v", updated, updated.ExtendedProperties.Private)
+	}
+}
+
+func TestUpdateReservationEventWithRandomID(t *testing.T) {
+	event := reservationEventWithDates(&cal.EventDateTime{Date: "2020-10-01"}, &cal.EventDateTime{Date: "2020-10-04"})
+	event.Id = "0d7ra3mvq2g5c1vnl1kl7f9rno"
+	server := newTestEventServer(t, event)
+	defer server.Close()
+
+	SetCalendarProperties(&props.CalendarProperties{
+		CalendarInfo: map[string]props.CalendarInfo{
+			"A1": {CalendarID: "a1@group.calendar.google.com"},
+		},
+	})
+	defer SetCalendarProperties(nil)
+
+	updated, err := UpdateReservationEvent(&dynamo.ReservationModel{
+		ReservationID: "reservation-1",
+		FromDate:      "2020-10-02",
+		ToDate:        "2020-10-05",
+		ApartmentCode: "A1",
+	}, "Jane Doe")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The fake applies the patch as is, an ID in the body would replace the existing one
+	if updated.Id != "0d7ra3mvq2g5c1vnl1kl7f9rno" || updated.Start.Date != "2020-10-02" {
+		t.Errorf("the ID of the existing event should be kept: %+v", updated)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	long := strings.Repeat("é", maxPropertyLength)
+	truncated := truncate(long)
+	if len(truncated) > maxPropertyLength || !strings.HasPrefix(long, truncated) {
+		t.Errorf("unexpected truncation, length: %d", len(truncated))
+	}
+}
@@ -0,0 +1,42 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/sylank/lavender-commons-go/circuit"
+	"github.com/sylank/lavender-commons-go/retry"
+)
+
+// Defaults of the circuit breaker wrapping the Google Calendar calls
+const (
+	DefaultFailureThreshold = 5
+	DefaultCoolDown         = 30 * time.Second
+)
+
+var breaker = newBreaker()
+
+func newBreaker() *circuit.Breaker {
+	breaker := circuit.NewBreaker("google-calendar", DefaultFailureThreshold, DefaultCoolDown)
+	// Only outages trip the breaker, a missing event is not a sign of degradation
+	breaker.IsFailure = retry.IsGoogleRetryable
+
+	return breaker
+}
+
+// SetCircuitBreaker replaces the breaker wrapping the Google Calendar calls
+func SetCircuitBreaker(circuitBreaker *circuit.Breaker) {
+	breaker = circuitBreaker
+}
+
+// CircuitBreakerStatus returns the state of the breaker for health checks
+func CircuitBreakerStatus() circuit.Status {
+	return breaker.Status()
+}
+
+// call runs fn with retries, every attempt is recorded by the breaker, so timed out attempts trip it as well;
+// an open breaker is not retried
+func call(fn func() error) error {
+	return retryPolicy.Do(func() error {
+		return breaker.Execute(fn)
+	})
+}
@@ -0,0 +1,163 @@
+package calendar
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	cal "google.golang.org/api/calendar/v3"
+
+	"github.com/sylank/lavender-commons-go/dates"
+)
+
+// freeBusyBatchSize is the maximum number of calendars of a single FreeBusy query
+const freeBusyBatchSize = 50
+
+// Availability of the apartments for a requested range
+type Availability struct {
+	Period dates.Range
+	// Busy holds the busy intervals of every apartment checked, empty for free apartments
+	Busy map[string][]dates.Range
+	// Free lists the apartments without busy intervals in the order they were requested
+	Free []string
+	// Errors holds the apartments Google could not check, e.g. calendars not shared with the client,
+	// they are never listed as free
+	Errors map[string]string
+}
+
+// IsFree returns true if the apartment has no busy interval in the range
+func (availability *Availability) IsFree(apartmentCode string) bool {
+	for _, free := range availability.Free {
+		if free == apartmentCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// QueryFreeBusy checks the calendars of the apartments with FreeBusy queries, every apartment of the calendar
+// properties is checked when no apartment is given. Transparent events do not count as busy.
+func QueryFreeBusy(period dates.Range, apartmentCodes []string) (*Availability, error) {
+	if len(apartmentCodes) == 0 {
+		apartmentCodes = configuredApartments()
+	}
+
+	availability := &Availability{
+		Period: period,
+		Busy:   map[string][]dates.Range{},
+		Free:   []string{},
+		Errors: map[string]string{},
+	}
+
+	apartmentsByCalendar := map[string][]string{}
+	var calendarIDs []string
+	for _, apartmentCode := range apartmentCodes {
+		calendarID, err := CalendarIDForApartment(apartmentCode)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := apartmentsByCalendar[calendarID]; !ok {
+			calendarIDs = append(calendarIDs, calendarID)
+		}
+		apartmentsByCalendar[calendarID] = append(apartmentsByCalendar[calendarID], apartmentCode)
+	}
+
+	for start := 0; start < len(calendarIDs); start += freeBusyBatchSize {
+		end := start + freeBusyBatchSize
+		if end > len(calendarIDs) {
+			end = len(calendarIDs)
+		}
+
+		response, err := queryFreeBusy(period, calendarIDs[start:end])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, calendarID := range calendarIDs[start:end] {
+			freeBusy, ok := response.Calendars[calendarID]
+			busy, err := busyRanges(freeBusy, period.Start.Location())
+			if !ok {
+				err = errors.New("calendar missing from the free/busy response")
+			}
+			for _, apartmentCode := range apartmentsByCalendar[calendarID] {
+				if err != nil {
+					availability.Errors[apartmentCode] = err.Error()
+					continue
+				}
+				availability.Busy[apartmentCode] = busy
+			}
+		}
+	}
+
+	for _, apartmentCode := range apartmentCodes {
+		if busy, ok := availability.Busy[apartmentCode]; ok && len(busy) == 0 {
+			availability.Free = append(availability.Free, apartmentCode)
+		}
+	}
+
+	return availability, nil
+}
+
+func queryFreeBusy(period dates.Range, calendarIDs []string) (*cal.FreeBusyResponse, error) {
+	request := &cal.FreeBusyRequest{
+		TimeMin:  period.Start.Format(time.RFC3339),
+		TimeMax:  period.End.Format(time.RFC3339),
+		TimeZone: period.Start.Location().String(),
+	}
+	for _, calendarID := range calendarIDs {
+		request.Items = append(request.Items, &cal.FreeBusyRequestItem{Id: calendarID})
+	}
+
+	var response *cal.FreeBusyResponse
+	err := call(func() error {
+		var err error
+		response, err = calendarClient.Freebusy.Query(request).Do()
+		return err
+	})
+	if err != nil {
+		log.Println("Unable to query free/busy", err)
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func busyRanges(freeBusy cal.FreeBusyCalendar, location *time.Location) ([]dates.Range, error) {
+	if len(freeBusy.Errors) > 0 {
+		return nil, errors.New("free/busy error: " + freeBusy.Errors[0].Reason)
+	}
+
+	busy := []dates.Range{}
+	for _, period := range freeBusy.Busy {
+		start, err := time.Parse(time.RFC3339, period.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := time.Parse(time.RFC3339, period.End)
+		if err != nil {
+			return nil, err
+		}
+
+		busyRange, err := dates.NewRange(start.In(location), end.In(location), false)
+		if err != nil {
+			return nil, err
+		}
+		busy = append(busy, busyRange)
+	}
+
+	return busy, nil
+}
+
+func configuredApartments() []string {
+	var apartmentCodes []string
+	if calendarProperties != nil {
+		for apartmentCode := range calendarProperties.CalendarInfo {
+			apartmentCodes = append(apartmentCodes, apartmentCode)
+		}
+	}
+	sort.Strings(apartmentCodes)
+
+	return apartmentCodes
+}
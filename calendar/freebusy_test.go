@@ -0,0 +1,79 @@
+package calendar
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/context"
+	cal "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+
+	"github.com/sylank/lavender-commons-go/dates"
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+func TestQueryFreeBusy(t *testing.T) {
+	calendarInfo := map[string]props.CalendarInfo{}
+	for i := 0; i < 60; i++ {
+		calendarInfo[fmt.Sprintf("A%02d", i)] = props.CalendarInfo{CalendarID: fmt.Sprintf("a%02d@group.calendar.google.com", i)}
+	}
+	SetCalendarProperties(&props.CalendarProperties{CalendarInfo: calendarInfo})
+	defer SetCalendarProperties(nil)
+
+	var batchSizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &cal.FreeBusyRequest{}
+		json.NewDecoder(r.Body).Decode(request)
+		batchSizes = append(batchSizes, len(request.Items))
+
+		response := &cal.FreeBusyResponse{Calendars: map[string]cal.FreeBusyCalendar{}}
+		for _, item := range request.Items {
+			switch item.Id {
+			case "a01@group.calendar.google.com":
+				response.Calendars[item.Id] = cal.FreeBusyCalendar{Busy: []*cal.TimePeriod{
+					{Start: "2020-10-02T12:00:00Z", End: "2020-10-05T08:00:00Z"},
+				}}
+			case "a02@group.calendar.google.com":
+				response.Calendars[item.Id] = cal.FreeBusyCalendar{Errors: []*cal.Error{{Domain: "global", Reason: "notFound"}}}
+			case "a03@group.calendar.google.com":
+			default:
+				response.Calendars[item.Id] = cal.FreeBusyCalendar{}
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	service, err := cal.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	calendarClient = service
+
+	location, _ := dates.LoadLocation("")
+	period, _ := dates.ParseRange("2020-10-01", "2020-10-08", location)
+	availability, err := QueryFreeBusy(period, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batchSizes) != 2 || batchSizes[0] != 50 || batchSizes[1] != 10 {
+		t.Errorf("expected batches of 50 and 10 calendars, got %v", batchSizes)
+	}
+	if len(availability.Free) != 57 || availability.Free[0] != "A00" || availability.IsFree("A01") {
+		t.Errorf("unexpected free apartments: %v", availability.Free)
+	}
+	if len(availability.Busy["A01"]) != 1 || availability.Busy["A01"][0].Nights() != 3 {
+		t.Errorf("unexpected busy intervals: %v", availability.Busy["A01"])
+	}
+	if availability.IsFree("A02") || availability.IsFree("A03") || len(availability.Errors) != 2 {
+		t.Errorf("apartments with errors should not be free: %v", availability.Errors)
+	}
+
+	if _, err := QueryFreeBusy(period, []string{"unknown"}); err == nil {
+		t.Errorf("expected error for unknown apartment")
+	}
+}
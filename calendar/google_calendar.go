@@ -1,124 +1,139 @@
 package calendar
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
-	"os"
+	"time"
 
-	"golang.org/x/net/context"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
+	"github.com/pkg/errors"
 	"google.golang.org/api/calendar/v3"
 
 	cal "google.golang.org/api/calendar/v3"
+
+	"github.com/sylank/lavender-commons-go/dates"
 )
 
 var calendarClient *calendar.Service
 
-// InitCalendarAPI ...
-func InitCalendarAPI(credentialsLocation string, tokenFilename string) error {
-	b, err := ioutil.ReadFile(credentialsLocation)
-	if err != nil {
-		log.Println(fmt.Sprintf("Error while reading file, filename: %s",credentialsLocation), err)
+// CalendarBooking is an event of an apartment calendar
+type CalendarBooking struct {
+	EventID       string
+	ReservationID string
+	ApartmentCode string
+	Summary       string
+	// Period is in the time zone of the apartment, the end is exclusive
+	Period dates.Range
+}
 
-		return err
+// QueryReservationsBetweenDate returns the events overlapping the RFC3339 time range ordered by start, cancelled
+// events are left out, the result is empty but never nil when there are no events
+func QueryReservationsBetweenDate(fromDate string, toDate string, calendarID string) ([]CalendarBooking, error) {
+	log.Println("Query events from google calendar, calendarId: " + calendarID)
+	bookings := []CalendarBooking{}
+	pageToken := ""
+	for {
+		listCall := calendarClient.Events.List(calendarID).ShowDeleted(false).
+			SingleEvents(true).TimeMin(fromDate).TimeMax(toDate).OrderBy("startTime")
+		if pageToken != "" {
+			listCall = listCall.PageToken(pageToken)
+		}
+
+		events, err := listEvents(listCall)
+		if err != nil {
+			log.Println(fmt.Sprintf("Unable to retrieve events"), err)
+			return nil, err
+		}
+
+		for _, event := range events.Items {
+			if IsMarkedCancelled(event) {
+				continue
+			}
+			booking, err := NewCalendarBooking(event, calendarID)
+			if err != nil {
+				log.Println("Skipping event with invalid dates, eventId: "+event.Id, err)
+				continue
+			}
+			bookings = append(bookings, *booking)
+		}
+
+		if events.NextPageToken == "" {
+			break
+		}
+		pageToken = events.NextPageToken
 	}
 
-	// If modifying these scopes, delete your previously saved token.json.
-	config, err := google.ConfigFromJSON(b, calendar.CalendarEventsScope)
-	if err != nil {
-		log.Println(fmt.Sprintf("Unable to parse client secret file to config"), err)
+	if len(bookings) == 0 {
+		log.Println("No upcoming events found.")
+	}
+	return bookings, nil
+}
 
-		return err
+// NewCalendarBooking maps the event, the apartment comes from the private properties of the event or from the
+// calendar properties when the event was not created for a reservation
+func NewCalendarBooking(event *cal.Event, calendarID string) (*CalendarBooking, error) {
+	apartmentCode := ""
+	if event.ExtendedProperties != nil {
+		apartmentCode = event.ExtendedProperties.Private[ApartmentCodeProperty]
+	}
+	if apartmentCode == "" {
+		apartmentCode = ApartmentForCalendar(calendarID)
 	}
-	client := getClient(config, tokenFilename)
 
-	calendarClient, err = calendar.New(client)
+	period, err := EventRange(event, ApartmentLocation(apartmentCode))
 	if err != nil {
-		log.Println(fmt.Sprintf("Unable to retrieve Calendar client"), err)
-
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &CalendarBooking{
+		EventID:       event.Id,
+		ReservationID: ReservationIDOf(event),
+		ApartmentCode: apartmentCode,
+		Summary:       event.Summary,
+		Period:        period,
+	}, nil
 }
 
-// Retrieve a token, saves the token, then returns the generated client.
-func getClient(config *oauth2.Config, tokenFilename string) *http.Client {
-	// The file token.json stores the user's access and refresh tokens, and is
-	// created automatically when the authorization flow completes for the first
-	// time.
-	tok, err := tokenFromFile(tokenFilename)
+// EventRange returns the period of the event in the location, all-day events end at the midnight after their
+// last day
+func EventRange(event *cal.Event, location *time.Location) (dates.Range, error) {
+	start, startAllDay, err := ParseEventDate(event.Start, location)
 	if err != nil {
-		tok = getTokenFromWeb(config)
-		saveToken(tokenFilename, tok)
-	}
-	return config.Client(context.Background(), tok)
-}
-
-// Request a token from the web, then returns the retrieved token.
-func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	fmt.Printf("Go to the following link in your browser then type the "+
-		"authorization code: \n%v\n", authURL)
-
-	var authCode string
-	if _, err := fmt.Scan(&authCode); err != nil {
-		log.Fatalf("Unable to read authorization code: %v", err)
+		return dates.Range{}, err
 	}
-
-	tok, err := config.Exchange(context.TODO(), authCode)
+	end, endAllDay, err := ParseEventDate(event.End, location)
 	if err != nil {
-		log.Fatalf("Unable to retrieve token from web: %v", err)
+		return dates.Range{}, err
 	}
-	return tok
-}
 
-// Retrieves a token from a local file.
-func tokenFromFile(file string) (*oauth2.Token, error) {
-	f, err := os.Open(file)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	tok := &oauth2.Token{}
-	err = json.NewDecoder(f).Decode(tok)
-	return tok, err
+	return dates.NewRange(start, end, startAllDay && endAllDay)
 }
 
-// Saves a token to a file path.
-func saveToken(path string, token *oauth2.Token) {
-	fmt.Printf("Saving credential file to: %s\n", path)
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		log.Fatalf("Unable to cache oauth token: %v", err)
+// ParseEventDate parses the date or the date-time of the event, all-day dates are midnight in the time zone of
+// the event or in the location when the event has none
+func ParseEventDate(eventDate *cal.EventDateTime, location *time.Location) (time.Time, bool, error) {
+	if eventDate == nil {
+		return time.Time{}, false, errors.New("missing event date")
 	}
-	defer f.Close()
-	json.NewEncoder(f).Encode(token)
-}
 
-// QueryReservationsBetweenDate ...
-func QueryReservationsBetweenDate(fromDate string, toDate string, calendarID string) (*cal.Events, error) {
-	log.Println("Query events from google calendar, calendarId: " + calendarID)
-	events, err := calendarClient.Events.List(calendarID).ShowDeleted(false).
-		SingleEvents(true).TimeMin(fromDate).TimeMax(toDate).OrderBy("startTime").Do()
-	if err != nil {
-		log.Println(fmt.Sprintf("Unable to retrieve events"), err)
-		return nil, err
+	if eventDate.TimeZone != "" {
+		loaded, err := time.LoadLocation(eventDate.TimeZone)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		location = loaded
 	}
-	if len(events.Items) == 0 {
-		log.Println("No upcoming events found.")
-		return nil, nil
+
+	value := eventDate.DateTime
+	if value == "" {
+		value = eventDate.Date
 	}
-	return events, nil
+
+	return dates.ParseDate(value, location)
 }
 
-// DeleteEventByID ...
-func DeleteEventByID(calendarID string, eventID string) error{
-	err := calendarClient.Events.Delete(calendarID, eventID).Do()
+// DeleteEventByID removes the event permanently, CancelEventByID keeps it on the calendar as cancelled
+func DeleteEventByID(calendarID string, eventID string) error {
+	err := deleteEvent(calendarClient.Events.Delete(calendarID, eventID))
 	if err != nil {
 		log.Println("Unable to delete event wit calendarId: " + calendarID + " eventID: " + eventID)
 		return err
@@ -0,0 +1,148 @@
+package calendar
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	cal "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+func newTestCalendarServer(t *testing.T, pages map[string]*cal.Events) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Query().Get("pageToken")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+
+	service, err := cal.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	calendarClient = service
+
+	return server
+}
+
+func TestQueryReservationsBetweenDate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		pages    map[string]*cal.Events
+		expected []string
+	}{
+		{
+			desc:     "empty calendar",
+			pages:    map[string]*cal.Events{"": {}},
+			expected: []string{},
+		},
+		{
+			desc: "every page is read",
+			pages: map[string]*cal.Events{
+				"": {
+					Items: []*cal.Event{{
+						Id:    "e1",
+						Start: &cal.EventDateTime{Date: "2020-10-01"},
+						End:   &cal.EventDateTime{Date: "2020-10-03"},
+					}},
+					NextPageToken: "page-2",
+				},
+				"page-2": {
+					Items: []*cal.Event{{
+						Id:    "e2",
+						Start: &cal.EventDateTime{DateTime: "2020-10-05T14:00:00+02:00"},
+						End:   &cal.EventDateTime{DateTime: "2020-10-07T10:00:00+02:00"},
+					}},
+				},
+			},
+			expected: []string{"e1", "e2"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			server := newTestCalendarServer(t, tC.pages)
+			defer server.Close()
+
+			bookings, err := QueryReservationsBetweenDate("2020-10-01T00:00:00Z", "2020-11-01T00:00:00Z", "a1@group.calendar.google.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bookings == nil || len(bookings) != len(tC.expected) {
+				t.Fatalf("unexpected bookings: %v", bookings)
+			}
+			for i, eventID := range tC.expected {
+				if bookings[i].EventID != eventID {
+					t.Errorf("expected %s, got %s", eventID, bookings[i].EventID)
+				}
+			}
+		})
+	}
+}
+
+func TestNewCalendarBooking(t *testing.T) {
+	event := reservationEventWithDates(&cal.EventDateTime{Date: "2020-10-01", TimeZone: "Europe/Budapest"}, &cal.EventDateTime{Date: "2020-10-04"})
+	booking, err := NewCalendarBooking(event, "a1@group.calendar.google.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	budapest, _ := time.LoadLocation("Europe/Budapest")
+	if !booking.Period.AllDay || !booking.Period.Start.Equal(time.Date(2020, 10, 1, 0, 0, 0, 0, budapest)) ||
+		!booking.Period.End.Equal(time.Date(2020, 10, 4, 0, 0, 0, 0, budapest)) || booking.Period.Nights() != 3 {
+		t.Errorf("unexpected dates: %+v", booking)
+	}
+	if booking.ReservationID != "reservation-1" || booking.ApartmentCode != "A1" {
+		t.Errorf("unexpected booking: %+v", booking)
+	}
+
+	timed := reservationEventWithDates(&cal.EventDateTime{DateTime: "2020-10-01T14:00:00+02:00"}, &cal.EventDateTime{DateTime: "2020-10-04T10:00:00+02:00"})
+	booking, err = NewCalendarBooking(timed, "a1@group.calendar.google.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if booking.Period.AllDay || !booking.Period.Start.Equal(time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected dates: %+v", booking)
+	}
+
+	SetCalendarProperties(&props.CalendarProperties{
+		CalendarInfo: map[string]props.CalendarInfo{
+			"A1": {CalendarID: "a1@group.calendar.google.com", TimeZone: "America/New_York"},
+		},
+	})
+	defer SetCalendarProperties(nil)
+
+	booking, err = NewCalendarBooking(reservationEventWithDates(&cal.EventDateTime{Date: "2020-10-01"}, &cal.EventDateTime{Date: "2020-10-04"}), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, _ := time.LoadLocation("America/New_York")
+	if !booking.Period.Start.Equal(time.Date(2020, 10, 1, 0, 0, 0, 0, newYork)) {
+		t.Errorf("all-day dates should be in the time zone of the apartment: %s", booking.Period.Start)
+	}
+
+	if _, err := NewCalendarBooking(&cal.Event{Id: "broken", Start: &cal.EventDateTime{Date: "tomorrow"}}, ""); err == nil {
+		t.Errorf("expected error for invalid date")
+	}
+}
+
+func reservationEventWithDates(start *cal.EventDateTime, end *cal.EventDateTime) *cal.Event {
+	return &cal.Event{
+		Id:    "e1",
+		Start: start,
+		End:   end,
+		ExtendedProperties: &cal.EventExtendedProperties{
+			Private: map[string]string{
+				ReservationIDProperty: "reservation-1",
+				ApartmentCodeProperty: "A1",
+			},
+		},
+	}
+}
@@ -0,0 +1,223 @@
+package calendar
+
+import (
+	"encoding/base32"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cal "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+
+	"github.com/sylank/lavender-commons-go/dates"
+	"github.com/sylank/lavender-commons-go/dynamo"
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+// Private extended properties of the reservation events
+const (
+	ReservationIDProperty = "reservationId"
+	ApartmentCodeProperty = "apartmentCode"
+)
+
+// DateLayout is the layout of the all-day event dates
+const DateLayout = dates.DateLayout
+
+// ErrUnknownApartment is returned when the apartment has no calendar in the calendar properties
+var ErrUnknownApartment = errors.New("no calendar configured for apartment")
+
+// ErrEventNotFound is returned when no event belongs to the reservation
+var ErrEventNotFound = errors.New("no calendar event found for reservation")
+
+var calendarProperties *props.CalendarProperties
+
+// SetCalendarProperties sets the properties used for resolving the calendar of the apartments
+func SetCalendarProperties(properties *props.CalendarProperties) {
+	calendarProperties = properties
+}
+
+// CalendarIDForApartment resolves the calendar ID of the apartment from the calendar properties
+func CalendarIDForApartment(apartmentCode string) (string, error) {
+	if calendarProperties == nil {
+		return "", errors.Wrap(ErrUnknownApartment, "calendar properties are not set")
+	}
+
+	calendarID := calendarProperties.GetCalendarID(apartmentCode)
+	if calendarID == "" {
+		return "", errors.Wrap(ErrUnknownApartment, apartmentCode)
+	}
+
+	return calendarID, nil
+}
+
+// ApartmentForCalendar returns the apartment of the calendar from the calendar properties, empty when unknown
+func ApartmentForCalendar(calendarID string) string {
+	if calendarProperties == nil {
+		return ""
+	}
+
+	for apartmentCode, info := range calendarProperties.CalendarInfo {
+		if info.CalendarID == calendarID {
+			return apartmentCode
+		}
+	}
+
+	return ""
+}
+
+// ApartmentLocation returns the time zone of the apartment from the calendar properties, dates.DefaultTimeZone
+// when not configured
+func ApartmentLocation(apartmentCode string) *time.Location {
+	timeZone := ""
+	if calendarProperties != nil {
+		timeZone = calendarProperties.GetTimeZone(apartmentCode)
+	}
+
+	location, err := dates.LoadLocation(timeZone)
+	if err != nil {
+		log.Println("Unknown time zone of apartment: "+apartmentCode, err)
+		location, _ = dates.LoadLocation("")
+	}
+
+	return location
+}
+
+// Prefix of the event IDs, keeps the IDs of short reservation IDs above the 5 characters required by Google
+const eventIDPrefix = "res"
+
+var eventIDEncoding = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// ReservationEventID returns the ID of the event of the reservation: the lowercase base32hex encoding of the
+// reservation ID, so the characters are within the a-v and 0-9 range accepted by Google
+func ReservationEventID(reservationID string) string {
+	return eventIDPrefix + strings.ToLower(eventIDEncoding.EncodeToString([]byte(reservationID)))
+}
+
+// ReservationEvent maps the reservation to an all-day event, the check-out day is the exclusive end of the event
+func ReservationEvent(reservation *dynamo.ReservationModel, guestName string) (*cal.Event, error) {
+	period, err := reservation.DateRange(ApartmentLocation(reservation.ApartmentCode))
+	if err != nil {
+		return nil, err
+	}
+
+	return &cal.Event{
+		Id:           ReservationEventID(reservation.ReservationID),
+		Summary:      fmt.Sprintf("%s - %s", guestName, reservation.ApartmentCode),
+		Description:  "Reservation: " + reservation.ReservationID,
+		Start:        &cal.EventDateTime{Date: period.StartDate()},
+		End:          &cal.EventDateTime{Date: period.EndDate()},
+		Transparency: "opaque",
+		ExtendedProperties: &cal.EventExtendedProperties{
+			Private: map[string]string{
+				ReservationIDProperty: reservation.ReservationID,
+				ApartmentCodeProperty: reservation.ApartmentCode,
+			},
+		},
+	}, nil
+}
+
+// CreateReservationEvent inserts the event of the reservation into the calendar of its apartment. The event ID is
+// derived from the reservation ID, so a retried insert returns the existing event instead of creating a duplicate
+// and an event deleted from the calendar is restored.
+func CreateReservationEvent(reservation *dynamo.ReservationModel, guestName string) (*cal.Event, error) {
+	calendarID, err := CalendarIDForApartment(reservation.ApartmentCode)
+	if err != nil {
+		log.Println("Unable to create event", err)
+		return nil, err
+	}
+
+	event, err := ReservationEvent(reservation, guestName)
+	if err != nil {
+		log.Println("Unable to create event for reservation: "+reservation.ReservationID, err)
+		return nil, err
+	}
+
+	created, err := doEvent(calendarClient.Events.Insert(calendarID, event).Do)
+	if apiErr, ok := errors.Cause(err).(*googleapi.Error); ok && apiErr.Code == http.StatusConflict {
+		return existingReservationEvent(calendarID, event)
+	}
+	if err != nil {
+		log.Println("Unable to insert event for reservation: "+reservation.ReservationID, err)
+		return nil, err
+	}
+
+	log.Println("Event created with event id: " + created.Id)
+	return created, nil
+}
+
+// existingReservationEvent returns the event already inserted with the ID of the reservation, a deleted event
+// is restored with the new content
+func existingReservationEvent(calendarID string, event *cal.Event) (*cal.Event, error) {
+	existing, err := GetEvent(calendarID, event.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !IsCancelled(existing) {
+		log.Println("Event already exists with event id: " + existing.Id)
+		return existing, nil
+	}
+
+	event.Status = "confirmed"
+	restored, err := updateEvent(calendarID, event)
+	if err != nil {
+		log.Println("Unable to restore deleted event with event id: "+event.Id, err)
+		return nil, err
+	}
+
+	log.Println("Deleted event restored with event id: " + restored.Id)
+	return restored, nil
+}
+
+// UpdateReservationEvent updates the dates and the summary of the event found by the reservation ID,
+// ErrEventNotFound is returned when the reservation has no event yet. A cancelled event stays cancelled, use
+// RestoreReservationEvent to activate it again.
+func UpdateReservationEvent(reservation *dynamo.ReservationModel, guestName string) (*cal.Event, error) {
+	calendarID, err := CalendarIDForApartment(reservation.ApartmentCode)
+	if err != nil {
+		log.Println("Unable to update event", err)
+		return nil, err
+	}
+
+	existing, err := FindReservationEvent(calendarID, reservation.ReservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := ReservationEvent(reservation, guestName)
+	if err != nil {
+		log.Println("Unable to update event for reservation: "+reservation.ReservationID, err)
+		return nil, err
+	}
+	// Events created before the IDs were derived from the reservation have random IDs, which cannot be changed
+	event.Id = ""
+	if IsMarkedCancelled(existing) {
+		keepCancelled(event, existing)
+	}
+
+	updated, err := doEvent(calendarClient.Events.Patch(calendarID, existing.Id, event).Do)
+	if err != nil {
+		log.Println("Unable to patch event with event id: "+existing.Id, err)
+		return nil, err
+	}
+
+	log.Println("Event updated with event id: " + updated.Id)
+	return updated, nil
+}
+
+// FindReservationEvent looks up the event by the reservation ID stored in its private extended properties
+func FindReservationEvent(calendarID string, reservationID string) (*cal.Event, error) {
+	events, err := listEvents(calendarClient.Events.List(calendarID).ShowDeleted(false).
+		PrivateExtendedProperty(ReservationIDProperty + "=" + reservationID))
+	if err != nil {
+		log.Println("Unable to look up event for reservation: "+reservationID, err)
+		return nil, err
+	}
+	if len(events.Items) == 0 {
+		return nil, errors.Wrap(ErrEventNotFound, reservationID)
+	}
+
+	return events.Items[0], nil
+}
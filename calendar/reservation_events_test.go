@@ -0,0 +1,177 @@
+package calendar
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	cal "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+
+	"github.com/sylank/lavender-commons-go/dynamo"
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+func TestReservationEvent(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		fromDate  string
+		toDate    string
+		start     string
+		end       string
+		expectErr bool
+	}{
+		{
+			desc:     "dates",
+			fromDate: "2020-10-01",
+			toDate:   "2020-10-04",
+			start:    "2020-10-01",
+			end:      "2020-10-04",
+		},
+		{
+			desc:     "timestamps",
+			fromDate: "2020-10-01T14:00:00+02:00",
+			toDate:   "2020-10-04T10:00:00+02:00",
+			start:    "2020-10-01",
+			end:      "2020-10-04",
+		},
+		{
+			desc:      "check-out before check-in",
+			fromDate:  "2020-10-04",
+			toDate:    "2020-10-01",
+			expectErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			event, err := ReservationEvent(&dynamo.ReservationModel{
+				ReservationID: "reservation-1",
+				FromDate:      tC.fromDate,
+				ToDate:        tC.toDate,
+				ApartmentCode: "A1",
+			}, "John Doe")
+			if tC.expectErr {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if event.Start.Date != tC.start || event.End.Date != tC.end {
+				t.Errorf("unexpected dates: %s - %s", event.Start.Date, event.End.Date)
+			}
+			if event.Summary != "John Doe - A1" {
+				t.Errorf("unexpected summary: %s", event.Summary)
+			}
+			private := event.ExtendedProperties.Private
+			if private[ReservationIDProperty] != "reservation-1" || private[ApartmentCodeProperty] != "A1" {
+				t.Errorf("unexpected private properties: %v", private)
+			}
+		})
+	}
+}
+
+func TestReservationEventID(t *testing.T) {
+	valid := regexp.MustCompile("^[a-v0-9]{5,}$")
+	for _, reservationID := range []string{"1", "reservation-1", "6f1c2a9e-6b1d-4c55-9d0e-8c1f0e8a7b21"} {
+		eventID := ReservationEventID(reservationID)
+		if !valid.MatchString(eventID) {
+			t.Errorf("invalid event id of %s: %s", reservationID, eventID)
+		}
+		if eventID != ReservationEventID(reservationID) {
+			t.Errorf("event id of %s is not stable", reservationID)
+		}
+	}
+	if ReservationEventID("reservation-1") == ReservationEventID("reservation-2") {
+		t.Error("event ids should differ")
+	}
+}
+
+func TestCreateReservationEventConflict(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		existingStatus string
+		expectedPuts   int
+	}{
+		{
+			desc:           "existing event is returned",
+			existingStatus: "confirmed",
+		},
+		{
+			desc:           "deleted event is restored",
+			existingStatus: "cancelled",
+			expectedPuts:   1,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			existing := &cal.Event{Id: ReservationEventID("reservation-1"), Status: tC.existingStatus, Summary: "John Doe - A1"}
+			puts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPost:
+					http.Error(w, `{"error":{"code":409,"message":"The requested identifier already exists."}}`, http.StatusConflict)
+				case http.MethodGet:
+					json.NewEncoder(w).Encode(existing)
+				case http.MethodPut:
+					puts++
+					json.NewDecoder(r.Body).Decode(existing)
+					json.NewEncoder(w).Encode(existing)
+				}
+			}))
+			defer server.Close()
+
+			service, err := cal.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithHTTPClient(server.Client()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			calendarClient = service
+
+			SetCalendarProperties(&props.CalendarProperties{
+				CalendarInfo: map[string]props.CalendarInfo{
+					"A1": {CalendarID: "a1@group.calendar.google.com"},
+				},
+			})
+			defer SetCalendarProperties(nil)
+
+			event, err := CreateReservationEvent(&dynamo.ReservationModel{
+				ReservationID: "reservation-1",
+				FromDate:      "2020-10-01",
+				ToDate:        "2020-10-04",
+				ApartmentCode: "A1",
+			}, "John Doe")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if event.Id != ReservationEventID("reservation-1") || IsCancelled(event) || puts != tC.expectedPuts {
+				t.Errorf("unexpected event: %+v, updates: %d", event, puts)
+			}
+		})
+	}
+}
+
+func TestCalendarIDForApartment(t *testing.T) {
+	SetCalendarProperties(&props.CalendarProperties{
+		CalendarInfo: map[string]props.CalendarInfo{
+			"A1": {CalendarID: "a1@group.calendar.google.com"},
+		},
+	})
+	defer SetCalendarProperties(nil)
+
+	calendarID, err := CalendarIDForApartment("A1")
+	if err != nil || calendarID != "a1@group.calendar.google.com" {
+		t.Errorf("unexpected calendar id: %s, err: %v", calendarID, err)
+	}
+
+	if _, err := CalendarIDForApartment("B2"); errors.Cause(err) != ErrUnknownApartment {
+		t.Errorf("expected ErrUnknownApartment, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package calendar
+
+import (
+	cal "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+
+	"github.com/sylank/lavender-commons-go/retry"
+)
+
+var retryPolicy = retry.GooglePolicy()
+
+// SetRetryPolicy sets the policy used for rate limited and failed Google Calendar calls
+func SetRetryPolicy(policy retry.Policy) {
+	retryPolicy = policy
+}
+
+func listEvents(listCall *cal.EventsListCall) (*cal.Events, error) {
+	var events *cal.Events
+	err := call(func() error {
+		var err error
+		events, err = listCall.Do()
+		return err
+	})
+
+	return events, err
+}
+
+func deleteEvent(deleteCall *cal.EventsDeleteCall) error {
+	return call(func() error {
+		return deleteCall.Do()
+	})
+}
+
+func doEvent(do func(opts ...googleapi.CallOption) (*cal.Event, error)) (*cal.Event, error) {
+	var event *cal.Event
+	err := call(func() error {
+		var err error
+		event, err = do()
+		return err
+	})
+
+	return event, err
+}
@@ -0,0 +1,76 @@
+package calendar
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/pkg/errors"
+	cal "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// ErrSyncTokenExpired is returned when Google invalidated the sync token, a full sync is needed
+var ErrSyncTokenExpired = errors.New("sync token expired")
+
+// ChangeSet holds the events changed since the previous sync, cancelled events included
+type ChangeSet struct {
+	Events        []*cal.Event
+	NextSyncToken string
+}
+
+// ListChanges returns the events changed since the sync token was issued, an empty sync token lists every event
+func ListChanges(calendarID string, syncToken string) (*ChangeSet, error) {
+	changes := &ChangeSet{}
+	pageToken := ""
+	for {
+		listCall := calendarClient.Events.List(calendarID).ShowDeleted(true).SingleEvents(true).MaxResults(250)
+		if syncToken != "" {
+			listCall = listCall.SyncToken(syncToken)
+		}
+		if pageToken != "" {
+			listCall = listCall.PageToken(pageToken)
+		}
+
+		events, err := listEvents(listCall)
+		if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusGone {
+			log.Println("Sync token expired, calendarId: " + calendarID)
+			return nil, ErrSyncTokenExpired
+		}
+		if err != nil {
+			log.Println("Unable to list changed events, calendarId: "+calendarID, err)
+			return nil, err
+		}
+
+		changes.Events = append(changes.Events, events.Items...)
+		if events.NextPageToken == "" {
+			changes.NextSyncToken = events.NextSyncToken
+			return changes, nil
+		}
+		pageToken = events.NextPageToken
+	}
+}
+
+// ReservationIDOf returns the reservation ID stored in the private properties of the event
+func ReservationIDOf(event *cal.Event) string {
+	if event.ExtendedProperties == nil {
+		return ""
+	}
+
+	return event.ExtendedProperties.Private[ReservationIDProperty]
+}
+
+// IsCancelled returns true for events deleted from the calendar
+func IsCancelled(event *cal.Event) bool {
+	return event.Status == "cancelled"
+}
+
+// GetEvent returns the event by its ID, cancelled events included
+func GetEvent(calendarID string, eventID string) (*cal.Event, error) {
+	event, err := doEvent(calendarClient.Events.Get(calendarID, eventID).Do)
+	if err != nil {
+		log.Println("Unable to get event with event id: "+eventID, err)
+		return nil, err
+	}
+
+	return event, nil
+}
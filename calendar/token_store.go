@@ -0,0 +1,283 @@
+package calendar
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"golang.org/x/oauth2"
+
+	"github.com/sylank/lavender-commons-go/crypto"
+)
+
+// TokenStore loads and saves the OAuth token, Load returns ErrNoToken when no token was saved yet
+type TokenStore interface {
+	Load() (*oauth2.Token, error)
+	Save(token *oauth2.Token) error
+}
+
+// FileTokenStore keeps the token in a local JSON file
+type FileTokenStore struct {
+	Path string
+}
+
+// NewFileTokenStore ...
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{Path: path}
+}
+
+// Load ...
+func (store *FileTokenStore) Load() (*oauth2.Token, error) {
+	data, err := ioutil.ReadFile(store.Path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoToken
+	}
+	if err != nil {
+		log.Println("Unable to read token file, filename: "+store.Path, err)
+		return nil, err
+	}
+
+	return TokenFromJSON(data)
+}
+
+// Save ...
+func (store *FileTokenStore) Save(token *oauth2.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(store.Path, data, 0600)
+	if err != nil {
+		log.Println("Unable to save token file, filename: "+store.Path, err)
+		return err
+	}
+
+	return nil
+}
+
+// TokenItem is the DynamoDB item of a token
+type TokenItem struct {
+	TokenID      string    `dynamodbav:"TokenId"`
+	AccessToken  string    `dynamodbav:"AccessToken"`
+	TokenType    string    `dynamodbav:"TokenType"`
+	RefreshToken string    `dynamodbav:"RefreshToken"`
+	Expiry       time.Time `dynamodbav:"Expiry"`
+}
+
+// DynamoTokenStore keeps the token in a DynamoDB table keyed by TokenId
+type DynamoTokenStore struct {
+	svc     dynamodbiface.DynamoDBAPI
+	table   string
+	tokenID string
+}
+
+// NewDynamoTokenStore ...
+func NewDynamoTokenStore(svc dynamodbiface.DynamoDBAPI, table string, tokenID string) *DynamoTokenStore {
+	return &DynamoTokenStore{
+		svc:     svc,
+		table:   table,
+		tokenID: tokenID,
+	}
+}
+
+// Load ...
+func (store *DynamoTokenStore) Load() (*oauth2.Token, error) {
+	result, err := store.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(store.table),
+		Key: map[string]*dynamodb.AttributeValue{
+			"TokenId": {
+				S: aws.String(store.tokenID),
+			},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		log.Println("Unable to load token, tokenId: "+store.tokenID, err)
+		return nil, err
+	}
+	if len(result.Item) == 0 {
+		return nil, ErrNoToken
+	}
+
+	item := TokenItem{}
+	err = dynamodbattribute.UnmarshalMap(result.Item, &item)
+	if err != nil {
+		log.Println("Unable to unmarshal token, tokenId: "+store.tokenID, err)
+		return nil, err
+	}
+
+	return &oauth2.Token{
+		AccessToken:  item.AccessToken,
+		TokenType:    item.TokenType,
+		RefreshToken: item.RefreshToken,
+		Expiry:       item.Expiry,
+	}, nil
+}
+
+// Save ...
+func (store *DynamoTokenStore) Save(token *oauth2.Token) error {
+	av, err := dynamodbattribute.MarshalMap(TokenItem{
+		TokenID:      store.tokenID,
+		AccessToken:  token.AccessToken,
+		TokenType:    token.TokenType,
+		RefreshToken: token.RefreshToken,
+		Expiry:       token.Expiry,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = store.svc.PutItem(&dynamodb.PutItemInput{
+		Item:      av,
+		TableName: aws.String(store.table),
+	})
+	if err != nil {
+		log.Println("Unable to save token, tokenId: "+store.tokenID, err)
+		return err
+	}
+
+	return nil
+}
+
+// EncryptedTokenStore encrypts the access and refresh tokens before passing them to the wrapped store
+type EncryptedTokenStore struct {
+	store TokenStore
+	key   []byte
+}
+
+// NewEncryptedTokenStore wraps the store, the key is an AES key of 16, 24 or 32 bytes
+func NewEncryptedTokenStore(store TokenStore, key []byte) *EncryptedTokenStore {
+	return &EncryptedTokenStore{
+		store: store,
+		key:   key,
+	}
+}
+
+// Load ...
+func (store *EncryptedTokenStore) Load() (*oauth2.Token, error) {
+	token, err := store.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted := *token
+	decrypted.AccessToken, err = store.open(token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	decrypted.RefreshToken, err = store.open(token.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decrypted, nil
+}
+
+// Save ...
+func (store *EncryptedTokenStore) Save(token *oauth2.Token) error {
+	var err error
+	encrypted := *token
+	encrypted.AccessToken, err = store.seal(token.AccessToken)
+	if err != nil {
+		return err
+	}
+	encrypted.RefreshToken, err = store.seal(token.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return store.store.Save(&encrypted)
+}
+
+func (store *EncryptedTokenStore) seal(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	return crypto.SealString(store.key, value)
+}
+
+func (store *EncryptedTokenStore) open(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	opened, err := crypto.OpenString(store.key, value)
+	if err != nil {
+		log.Println("Unable to decrypt token", err)
+		return "", err
+	}
+
+	return opened, nil
+}
+
+// PersistingTokenSource saves every new token of the wrapped source, so refreshed tokens survive restarts
+type PersistingTokenSource struct {
+	source oauth2.TokenSource
+	store  TokenStore
+
+	mutex   sync.Mutex
+	current *oauth2.Token
+}
+
+// NewPersistingTokenSource wraps the source, current is the token the source was created with
+func NewPersistingTokenSource(source oauth2.TokenSource, store TokenStore, current *oauth2.Token) *PersistingTokenSource {
+	return &PersistingTokenSource{
+		source:  source,
+		store:   store,
+		current: current,
+	}
+}
+
+// Token returns the token of the wrapped source and saves it when it changed, failing to save is only logged
+func (tokenSource *PersistingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := tokenSource.source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	tokenSource.mutex.Lock()
+	defer tokenSource.mutex.Unlock()
+
+	if tokenSource.current != nil && tokenSource.current.AccessToken == token.AccessToken {
+		return token, nil
+	}
+
+	// The refresh token is not returned by every refresh, the stored one stays valid then
+	if token.RefreshToken == "" && tokenSource.current != nil {
+		saved := *token
+		saved.RefreshToken = tokenSource.current.RefreshToken
+		token = &saved
+	}
+
+	if err := tokenSource.store.Save(token); err != nil {
+		log.Println("Unable to persist refreshed token", err)
+	} else {
+		log.Println("Refreshed token persisted")
+	}
+	tokenSource.current = token
+
+	return token, nil
+}
+
+// InitCalendarAPIWithTokenStore initializes the client with the token of the store, refreshed tokens are
+// saved back to the store
+func InitCalendarAPIWithTokenStore(config *oauth2.Config, store TokenStore) error {
+	tok, err := store.Load()
+	if err != nil {
+		log.Println("Unable to load token", err)
+		return err
+	}
+
+	source := config.TokenSource(tokenContext(), tok)
+
+	return InitCalendarAPIWithTokenSource(NewPersistingTokenSource(source, store, tok))
+}
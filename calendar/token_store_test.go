@@ -0,0 +1,150 @@
+package calendar
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"golang.org/x/oauth2"
+)
+
+type memoryTokenStore struct {
+	token *oauth2.Token
+	saves int
+}
+
+func (store *memoryTokenStore) Load() (*oauth2.Token, error) {
+	if store.token == nil {
+		return nil, ErrNoToken
+	}
+
+	return store.token, nil
+}
+
+func (store *memoryTokenStore) Save(token *oauth2.Token) error {
+	store.token = token
+	store.saves++
+
+	return nil
+}
+
+type memoryTokenTable struct {
+	dynamodbiface.DynamoDBAPI
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+func (table *memoryTokenTable) GetItem(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: table.items[*input.Key["TokenId"].S]}, nil
+}
+
+func (table *memoryTokenTable) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	table.items[*input.Item["TokenId"].S] = input.Item
+
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+type sequenceTokenSource struct {
+	tokens []*oauth2.Token
+}
+
+func (source *sequenceTokenSource) Token() (*oauth2.Token, error) {
+	token := source.tokens[0]
+	if len(source.tokens) > 1 {
+		source.tokens = source.tokens[1:]
+	}
+
+	return token, nil
+}
+
+var testToken = &oauth2.Token{
+	AccessToken:  "access",
+	TokenType:    "Bearer",
+	RefreshToken: "refresh",
+	Expiry:       time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func TestTokenStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		desc  string
+		store TokenStore
+	}{
+		{
+			desc:  "file",
+			store: NewFileTokenStore(filepath.Join(dir, "token.json")),
+		},
+		{
+			desc:  "dynamo",
+			store: NewDynamoTokenStore(&memoryTokenTable{items: map[string]map[string]*dynamodb.AttributeValue{}}, "tokens", "calendar"),
+		},
+		{
+			desc:  "encrypted",
+			store: NewEncryptedTokenStore(&memoryTokenStore{}, []byte("12345678901234567890123456789012")),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := tC.store.Load(); err != ErrNoToken {
+				t.Fatalf("expected ErrNoToken from empty store, got %v", err)
+			}
+
+			if err := tC.store.Save(testToken); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded, err := tC.store.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.AccessToken != testToken.AccessToken || loaded.RefreshToken != testToken.RefreshToken ||
+				loaded.TokenType != testToken.TokenType || !loaded.Expiry.Equal(testToken.Expiry) {
+				t.Errorf("unexpected token: %+v", loaded)
+			}
+		})
+	}
+}
+
+func TestEncryptedTokenStoreHidesTokens(t *testing.T) {
+	inner := &memoryTokenStore{}
+	store := NewEncryptedTokenStore(inner, []byte("12345678901234567890123456789012"))
+
+	if err := store.Save(testToken); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(inner.token.AccessToken, "access") || strings.Contains(inner.token.RefreshToken, "refresh") {
+		t.Errorf("tokens are stored in plain text: %+v", inner.token)
+	}
+	if testToken.AccessToken != "access" {
+		t.Errorf("the saved token should not be modified")
+	}
+}
+
+func TestPersistingTokenSource(t *testing.T) {
+	store := &memoryTokenStore{token: testToken}
+	refreshed := &oauth2.Token{AccessToken: "refreshed", TokenType: "Bearer"}
+	source := NewPersistingTokenSource(&sequenceTokenSource{tokens: []*oauth2.Token{testToken, refreshed}}, store, testToken)
+
+	source.Token()
+	if store.saves != 0 {
+		t.Errorf("unchanged token should not be saved")
+	}
+
+	source.Token()
+	source.Token()
+	if store.saves != 1 {
+		t.Errorf("refreshed token should be saved once, saves: %d", store.saves)
+	}
+	if store.token.AccessToken != "refreshed" || store.token.RefreshToken != "refresh" {
+		t.Errorf("unexpected saved token: %+v", store.token)
+	}
+}
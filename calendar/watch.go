@@ -0,0 +1,63 @@
+package calendar
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	cal "google.golang.org/api/calendar/v3"
+)
+
+// WebHookChannelType is the only channel type supported by Google Calendar
+const WebHookChannelType = "web_hook"
+
+// WatchEvents registers a push notification channel for the events of the calendar, Google posts to the
+// address with the token in the X-Goog-Channel-Token header until the channel expires
+func WatchEvents(calendarID string, channelID string, address string, token string, ttl time.Duration) (*cal.Channel, error) {
+	channel := &cal.Channel{
+		Id:      channelID,
+		Type:    WebHookChannelType,
+		Address: address,
+		Token:   token,
+	}
+	if ttl > 0 {
+		channel.Params = map[string]string{"ttl": strconv.FormatInt(int64(ttl/time.Second), 10)}
+	}
+
+	var created *cal.Channel
+	err := call(func() error {
+		var err error
+		created, err = calendarClient.Events.Watch(calendarID, channel).Do()
+		return err
+	})
+	if err != nil {
+		log.Println("Unable to watch events, calendarId: "+calendarID, err)
+		return nil, err
+	}
+
+	log.Println("Watching events, calendarId: " + calendarID + " channelId: " + created.Id)
+	return created, nil
+}
+
+// StopChannel stops the push notifications of the channel
+func StopChannel(channelID string, resourceID string) error {
+	err := call(func() error {
+		return calendarClient.Channels.Stop(&cal.Channel{Id: channelID, ResourceId: resourceID}).Do()
+	})
+	if err != nil {
+		log.Println("Unable to stop channel, channelId: "+channelID, err)
+		return err
+	}
+
+	log.Println("Channel stopped, channelId: " + channelID)
+	return nil
+}
+
+// ChannelExpiration returns the expiration of the channel, Google sends it in Unix milliseconds
+func ChannelExpiration(channel *cal.Channel) time.Time {
+	if channel.Expiration == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, channel.Expiration*int64(time.Millisecond))
+}
@@ -0,0 +1,412 @@
+package calendarsync
+
+import (
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	cal "google.golang.org/api/calendar/v3"
+
+	"github.com/sylank/lavender-commons-go/calendar"
+	"github.com/sylank/lavender-commons-go/dynamo"
+)
+
+// ConflictPolicy decides which side wins when an event changed in the calendar differs from its reservation
+type ConflictPolicy int
+
+// Conflict policies, deleting a reservation always cancels its event regardless of the policy
+const (
+	// DatabaseWins restores the event from the reservation
+	DatabaseWins ConflictPolicy = iota
+	// CalendarWins copies the dates of the event to the reservation and cancels the reservation of a deleted or
+	// cancelled event
+	CalendarWins
+)
+
+// Engine syncs the reservations of an apartment with its calendar. Changes are pulled from the calendar with
+// sync tokens; reservations not among the changes are looked up one by one until their check-out, so the
+// database side needs no change tracking. An incremental sync costs one events.list call per upcoming
+// reservation, plus one per event deleted in the calendar to find a replacement event before recreating it.
+type Engine struct {
+	Policy ConflictPolicy
+	// DryRun only reports the actions
+	DryRun bool
+
+	store   ReservationStore
+	gateway CalendarGateway
+	tokens  SyncTokenStore
+	now     func() time.Time
+}
+
+// NewEngine ...
+func NewEngine(store ReservationStore, gateway CalendarGateway, tokens SyncTokenStore) *Engine {
+	return &Engine{
+		Policy:  DatabaseWins,
+		store:   store,
+		gateway: gateway,
+		tokens:  tokens,
+		now:     time.Now,
+	}
+}
+
+// SyncAll syncs the apartments one after the other, a failing apartment does not stop the others
+func (engine *Engine) SyncAll(apartmentCodes []string) ([]*Report, error) {
+	var reports []*Report
+	var lastErr error
+	for _, apartmentCode := range apartmentCodes {
+		report, err := engine.Sync(apartmentCode)
+		if err != nil {
+			lastErr = err
+		}
+		if report != nil {
+			reports = append(reports, report)
+		}
+	}
+
+	return reports, lastErr
+}
+
+// Sync reconciles the apartment, the sync token is only advanced when every action succeeded, so failed
+// changes are pulled again on the next run
+func (engine *Engine) Sync(apartmentCode string) (*Report, error) {
+	report := &Report{
+		ApartmentCode: apartmentCode,
+		DryRun:        engine.DryRun,
+		Actions:       []Action{},
+		Unmatched:     []string{},
+		Orphaned:      []string{},
+		Errors:        []string{},
+	}
+
+	calendarID, err := engine.gateway.CalendarID(apartmentCode)
+	if err != nil {
+		return nil, err
+	}
+	report.CalendarID = calendarID
+
+	changes, err := engine.pullChanges(report)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations, err := engine.store.ListReservations(apartmentCode)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]*dynamo.ReservationModel{}
+	for i := range reservations {
+		byID[reservations[i].ReservationID] = &reservations[i]
+	}
+
+	seen := map[string]bool{}
+	for _, event := range changes.Events {
+		event, reservationID := engine.resolveEvent(report, event)
+		if reservationID == "" {
+			continue
+		}
+
+		reservation, ok := byID[reservationID]
+		if !ok {
+			if !calendar.IsCancelled(event) && !calendar.IsMarkedCancelled(event) {
+				report.Orphaned = append(report.Orphaned, event.Id)
+			}
+			continue
+		}
+
+		seen[reservationID] = true
+		engine.reconcileChangedEvent(report, reservation, event)
+	}
+
+	today := engine.now().Format(calendar.DateLayout)
+	for _, reservation := range reservations {
+		if seen[reservation.ReservationID] || !isUpcoming(&reservation, today) {
+			continue
+		}
+
+		engine.reconcileReservation(report, &reservation)
+	}
+
+	if report.Failed() || engine.DryRun {
+		log.Println("Sync token is not advanced, " + report.String())
+		return report, nil
+	}
+
+	if err := engine.tokens.SaveSyncToken(calendarID, changes.NextSyncToken); err != nil {
+		report.addError(err)
+	}
+
+	log.Println("Calendar synced, " + report.String())
+	return report, nil
+}
+
+func (engine *Engine) pullChanges(report *Report) (*calendar.ChangeSet, error) {
+	syncToken, err := engine.tokens.LoadSyncToken(report.CalendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := engine.gateway.ListChanges(report.CalendarID, syncToken)
+	if errors.Cause(err) == calendar.ErrSyncTokenExpired {
+		log.Println("Falling back to full sync, calendarId: " + report.CalendarID)
+		syncToken = ""
+		changes, err = engine.gateway.ListChanges(report.CalendarID, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report.FullSync = syncToken == ""
+	report.EventsPulled = len(changes.Events)
+
+	return changes, nil
+}
+
+// resolveEvent returns the event with its reservation ID, cancelled events arrive without their properties
+// in incremental syncs, they are fetched again
+func (engine *Engine) resolveEvent(report *Report, event *cal.Event) (*cal.Event, string) {
+	reservationID := calendar.ReservationIDOf(event)
+	if reservationID == "" && calendar.IsCancelled(event) {
+		fetched, err := engine.gateway.GetEvent(report.CalendarID, event.Id)
+		if err != nil {
+			report.addError(errors.Wrap(err, "get cancelled event "+event.Id))
+			return event, ""
+		}
+		event = fetched
+		reservationID = calendar.ReservationIDOf(event)
+	}
+
+	if reservationID == "" && !calendar.IsCancelled(event) {
+		report.Unmatched = append(report.Unmatched, event.Id)
+	}
+
+	return event, reservationID
+}
+
+// reconcileChangedEvent handles an event changed in the calendar since the previous sync
+func (engine *Engine) reconcileChangedEvent(report *Report, reservation *dynamo.ReservationModel, event *cal.Event) {
+	switch {
+	case reservation.Deleted && (calendar.IsCancelled(event) || calendar.IsMarkedCancelled(event)):
+		report.InSync++
+	case reservation.Deleted:
+		engine.cancelEvent(report, reservation, event)
+	case calendar.IsMarkedCancelled(event):
+		report.Conflicts++
+		if engine.Policy == CalendarWins {
+			engine.cancelReservation(report, reservation, event, "event was cancelled in the calendar")
+		} else {
+			engine.restoreEvent(report, reservation, event)
+		}
+	case calendar.IsCancelled(event):
+		report.Conflicts++
+		if engine.Policy == CalendarWins {
+			engine.cancelReservation(report, reservation, event, "event was deleted from the calendar")
+		} else {
+			engine.recreateEvent(report, reservation)
+		}
+	default:
+		engine.reconcileDates(report, reservation, event, true)
+	}
+}
+
+// reconcileReservation handles an upcoming reservation whose event did not change since the previous sync,
+// the database is the newer side then
+func (engine *Engine) reconcileReservation(report *Report, reservation *dynamo.ReservationModel) {
+	var event *cal.Event
+	if !report.FullSync {
+		var err error
+		event, err = engine.gateway.FindReservationEvent(report.CalendarID, reservation.ReservationID)
+		if err != nil && errors.Cause(err) != calendar.ErrEventNotFound {
+			report.addError(errors.Wrap(err, "find event of "+reservation.ReservationID))
+			return
+		}
+	}
+
+	switch {
+	case event == nil && reservation.Deleted:
+		report.InSync++
+	case event == nil:
+		engine.createEvent(report, reservation, "reservation has no event")
+	case reservation.Deleted && calendar.IsMarkedCancelled(event):
+		report.InSync++
+	case reservation.Deleted:
+		engine.cancelEvent(report, reservation, event)
+	case calendar.IsMarkedCancelled(event):
+		engine.restoreEvent(report, reservation, event)
+	default:
+		engine.reconcileDates(report, reservation, event, false)
+	}
+}
+
+// recreateEvent restores the deleted event of an active reservation, another event of the reservation, e.g. one
+// created by a previous run or by hand, is reconciled instead of creating a duplicate
+func (engine *Engine) recreateEvent(report *Report, reservation *dynamo.ReservationModel) {
+	event, err := engine.gateway.FindReservationEvent(report.CalendarID, reservation.ReservationID)
+	if err != nil && errors.Cause(err) != calendar.ErrEventNotFound {
+		report.addError(errors.Wrap(err, "find event of "+reservation.ReservationID))
+		return
+	}
+
+	switch {
+	case event == nil:
+		engine.createEvent(report, reservation, "event was deleted from the calendar")
+	case calendar.IsMarkedCancelled(event):
+		engine.restoreEvent(report, reservation, event)
+	default:
+		engine.reconcileDates(report, reservation, event, false)
+	}
+}
+
+// reconcileDates compares the dates of the active event and reservation, the conflict policy only applies
+// when the event changed since the previous sync
+func (engine *Engine) reconcileDates(report *Report, reservation *dynamo.ReservationModel, event *cal.Event, eventChanged bool) {
+	fromDate, toDate, err := expectedDates(reservation)
+	if err != nil {
+		report.addError(errors.Wrap(err, reservation.ReservationID))
+		return
+	}
+	eventFromDate, eventToDate, err := eventDates(reservation, event)
+	if err != nil {
+		report.addError(errors.Wrap(err, "dates of event "+event.Id))
+		return
+	}
+	if eventFromDate == fromDate && eventToDate == toDate {
+		report.InSync++
+		return
+	}
+
+	if !eventChanged {
+		engine.updateEvent(report, reservation, event, eventFromDate, eventToDate)
+		return
+	}
+
+	report.Conflicts++
+	if engine.Policy == CalendarWins {
+		engine.updateReservation(report, reservation, event, eventFromDate, eventToDate)
+	} else {
+		engine.updateEvent(report, reservation, event, eventFromDate, eventToDate)
+	}
+}
+
+func (engine *Engine) createEvent(report *Report, reservation *dynamo.ReservationModel, detail string) {
+	action := Action{Type: CreateEvent, ReservationID: reservation.ReservationID, Detail: detail}
+	if engine.DryRun {
+		report.addAction(action, nil)
+		return
+	}
+
+	guestName, err := engine.store.GuestName(reservation.UserID)
+	if err != nil {
+		report.addAction(action, err)
+		return
+	}
+
+	event, err := engine.gateway.CreateReservationEvent(reservation, guestName)
+	if err == nil {
+		action.EventID = event.Id
+	}
+	report.addAction(action, err)
+}
+
+func (engine *Engine) updateEvent(report *Report, reservation *dynamo.ReservationModel, event *cal.Event, fromDate string, toDate string) {
+	action := Action{
+		Type:          UpdateEvent,
+		ReservationID: reservation.ReservationID,
+		EventID:       event.Id,
+		Detail:        "event dates " + fromDate + " - " + toDate,
+	}
+	if engine.DryRun {
+		report.addAction(action, nil)
+		return
+	}
+
+	guestName, err := engine.store.GuestName(reservation.UserID)
+	if err != nil {
+		report.addAction(action, err)
+		return
+	}
+
+	_, err = engine.gateway.UpdateReservationEvent(reservation, guestName)
+	report.addAction(action, err)
+}
+
+func (engine *Engine) cancelEvent(report *Report, reservation *dynamo.ReservationModel, event *cal.Event) {
+	action := Action{Type: CancelEvent, ReservationID: reservation.ReservationID, EventID: event.Id, Detail: "reservation was deleted"}
+	if engine.DryRun {
+		report.addAction(action, nil)
+		return
+	}
+
+	report.addAction(action, engine.gateway.CancelEvent(report.CalendarID, event.Id, action.Detail))
+}
+
+func (engine *Engine) restoreEvent(report *Report, reservation *dynamo.ReservationModel, event *cal.Event) {
+	action := Action{Type: RestoreEvent, ReservationID: reservation.ReservationID, EventID: event.Id, Detail: "reservation is active"}
+	if engine.DryRun {
+		report.addAction(action, nil)
+		return
+	}
+
+	report.addAction(action, engine.gateway.RestoreEvent(report.CalendarID, event.Id))
+}
+
+func (engine *Engine) updateReservation(report *Report, reservation *dynamo.ReservationModel, event *cal.Event, fromDate string, toDate string) {
+	action := Action{
+		Type:          UpdateReservation,
+		ReservationID: reservation.ReservationID,
+		EventID:       event.Id,
+		Detail:        "reservation dates " + reservation.FromDate + " - " + reservation.ToDate,
+	}
+	if engine.DryRun {
+		report.addAction(action, nil)
+		return
+	}
+
+	report.addAction(action, engine.store.UpdateReservationDates(reservation, fromDate, toDate))
+}
+
+func (engine *Engine) cancelReservation(report *Report, reservation *dynamo.ReservationModel, event *cal.Event, detail string) {
+	action := Action{Type: CancelReservation, ReservationID: reservation.ReservationID, EventID: event.Id, Detail: detail}
+	if engine.DryRun {
+		report.addAction(action, nil)
+		return
+	}
+
+	report.addAction(action, engine.store.CancelReservation(reservation, detail))
+}
+
+// expectedDates returns the all-day dates the event of the reservation should have
+func expectedDates(reservation *dynamo.ReservationModel) (string, string, error) {
+	event, err := calendar.ReservationEvent(reservation, "")
+	if err != nil {
+		return "", "", err
+	}
+
+	return event.Start.Date, event.End.Date, nil
+}
+
+// eventDates returns the event as whole days in the time zone of the apartment, events edited by hand may have
+// a start and end time instead of all-day dates
+func eventDates(reservation *dynamo.ReservationModel, event *cal.Event) (string, string, error) {
+	if event.Start == nil || event.End == nil {
+		return "", "", errors.New("event has no start or end")
+	}
+
+	eventRange, err := calendar.EventRange(event, calendar.ApartmentLocation(reservation.ApartmentCode))
+	if err != nil {
+		return "", "", err
+	}
+	days := eventRange.Days()
+
+	return days.StartDate(), days.EndDate(), nil
+}
+
+func isUpcoming(reservation *dynamo.ReservationModel, today string) bool {
+	_, toDate, err := expectedDates(reservation)
+	if err != nil {
+		log.Println("Skipping reservation with invalid dates: "+reservation.ReservationID, err)
+		return false
+	}
+
+	return toDate >= today
+}
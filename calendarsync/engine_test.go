@@ -0,0 +1,407 @@
+package calendarsync
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	cal "google.golang.org/api/calendar/v3"
+
+	"github.com/sylank/lavender-commons-go/calendar"
+	"github.com/sylank/lavender-commons-go/dynamo"
+)
+
+const testCalendarID = "a1@group.calendar.google.com"
+
+type memoryReservationStore struct {
+	reservations []dynamo.ReservationModel
+	cancelled    []string
+	updated      map[string][2]string
+}
+
+func (store *memoryReservationStore) ListReservations(apartmentCode string) ([]dynamo.ReservationModel, error) {
+	return append([]dynamo.ReservationModel{}, store.reservations...), nil
+}
+
+func (store *memoryReservationStore) UpdateReservationDates(reservation *dynamo.ReservationModel, fromDate string, toDate string) error {
+	store.updated[reservation.ReservationID] = [2]string{fromDate, toDate}
+	return nil
+}
+
+func (store *memoryReservationStore) CancelReservation(reservation *dynamo.ReservationModel, reason string) error {
+	store.cancelled = append(store.cancelled, reservation.ReservationID)
+	return nil
+}
+
+func (store *memoryReservationStore) GuestName(userID string) (string, error) {
+	return "Guest " + userID, nil
+}
+
+type fakeGateway struct {
+	changes        []*cal.Event
+	events         map[string]*cal.Event
+	expiredToken   string
+	nextSyncToken  string
+	listedTokens   []string
+	created        []string
+	updated        []string
+	cancelled      []string
+	restored       []string
+	failOnCreation bool
+}
+
+func (gateway *fakeGateway) CalendarID(apartmentCode string) (string, error) {
+	return testCalendarID, nil
+}
+
+func (gateway *fakeGateway) ListChanges(calendarID string, syncToken string) (*calendar.ChangeSet, error) {
+	gateway.listedTokens = append(gateway.listedTokens, syncToken)
+	if syncToken != "" && syncToken == gateway.expiredToken {
+		return nil, calendar.ErrSyncTokenExpired
+	}
+
+	return &calendar.ChangeSet{Events: gateway.changes, NextSyncToken: gateway.nextSyncToken}, nil
+}
+
+func (gateway *fakeGateway) GetEvent(calendarID string, eventID string) (*cal.Event, error) {
+	return gateway.events[eventID], nil
+}
+
+func (gateway *fakeGateway) FindReservationEvent(calendarID string, reservationID string) (*cal.Event, error) {
+	for _, event := range gateway.events {
+		if calendar.ReservationIDOf(event) == reservationID && !calendar.IsCancelled(event) {
+			return event, nil
+		}
+	}
+
+	return nil, calendar.ErrEventNotFound
+}
+
+func (gateway *fakeGateway) CreateReservationEvent(reservation *dynamo.ReservationModel, guestName string) (*cal.Event, error) {
+	if gateway.failOnCreation {
+		return nil, errors.New("calendar unavailable")
+	}
+	gateway.created = append(gateway.created, reservation.ReservationID)
+
+	return &cal.Event{Id: "new-" + reservation.ReservationID}, nil
+}
+
+func (gateway *fakeGateway) UpdateReservationEvent(reservation *dynamo.ReservationModel, guestName string) (*cal.Event, error) {
+	gateway.updated = append(gateway.updated, reservation.ReservationID)
+	return &cal.Event{}, nil
+}
+
+func (gateway *fakeGateway) CancelEvent(calendarID string, eventID string, reason string) error {
+	gateway.cancelled = append(gateway.cancelled, eventID)
+	return nil
+}
+
+func (gateway *fakeGateway) RestoreEvent(calendarID string, eventID string) error {
+	gateway.restored = append(gateway.restored, eventID)
+	return nil
+}
+
+type memorySyncTokenStore struct {
+	tokens map[string]string
+}
+
+func (store *memorySyncTokenStore) LoadSyncToken(calendarID string) (string, error) {
+	return store.tokens[calendarID], nil
+}
+
+func (store *memorySyncTokenStore) SaveSyncToken(calendarID string, syncToken string) error {
+	store.tokens[calendarID] = syncToken
+	return nil
+}
+
+func reservationEvent(eventID string, reservationID string, fromDate string, toDate string) *cal.Event {
+	return &cal.Event{
+		Id:     eventID,
+		Status: "confirmed",
+		Start:  &cal.EventDateTime{Date: fromDate},
+		End:    &cal.EventDateTime{Date: toDate},
+		ExtendedProperties: &cal.EventExtendedProperties{
+			Private: map[string]string{calendar.ReservationIDProperty: reservationID},
+		},
+	}
+}
+
+func timedReservationEvent(eventID string, reservationID string, start string, end string) *cal.Event {
+	event := reservationEvent(eventID, reservationID, "", "")
+	event.Start = &cal.EventDateTime{DateTime: start}
+	event.End = &cal.EventDateTime{DateTime: end}
+
+	return event
+}
+
+func markedCancelled(event *cal.Event) *cal.Event {
+	event.Summary = calendar.CancelledPrefix + event.Summary
+	event.Transparency = "transparent"
+	event.ExtendedProperties.Private[calendar.CancelledProperty] = "true"
+
+	return event
+}
+
+func reservation(reservationID string, fromDate string, toDate string, deleted bool) dynamo.ReservationModel {
+	return dynamo.ReservationModel{
+		ReservationID: reservationID,
+		FromDate:      fromDate,
+		ToDate:        toDate,
+		UserID:        "user-" + reservationID,
+		Deleted:       deleted,
+		ApartmentCode: "A1",
+	}
+}
+
+func newTestEngine(store *memoryReservationStore, gateway *fakeGateway, syncToken string) (*Engine, *memorySyncTokenStore) {
+	tokens := &memorySyncTokenStore{tokens: map[string]string{testCalendarID: syncToken}}
+	engine := NewEngine(store, gateway, tokens)
+	engine.now = func() time.Time {
+		return time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	}
+
+	return engine, tokens
+}
+
+func TestFullSync(t *testing.T) {
+	store := &memoryReservationStore{reservations: []dynamo.ReservationModel{
+		reservation("in-sync", "2020-10-05", "2020-10-08", false),
+		reservation("missing-event", "2020-10-10", "2020-10-12", false),
+		reservation("deleted", "2020-10-15", "2020-10-17", true),
+		reservation("past", "2020-09-01", "2020-09-03", false),
+	}}
+	gateway := &fakeGateway{
+		nextSyncToken: "token-1",
+		changes: []*cal.Event{
+			reservationEvent("e1", "in-sync", "2020-10-05", "2020-10-08"),
+			reservationEvent("e3", "deleted", "2020-10-15", "2020-10-17"),
+			reservationEvent("e4", "unknown", "2020-10-20", "2020-10-22"),
+			{Id: "e5", Status: "confirmed", Summary: "Maintenance"},
+		},
+	}
+	engine, tokens := newTestEngine(store, gateway, "")
+
+	report, err := engine.Sync("A1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.FullSync || report.InSync != 1 {
+		t.Errorf("unexpected report: %s", report)
+	}
+	if len(gateway.created) != 1 || gateway.created[0] != "missing-event" {
+		t.Errorf("expected event created for missing-event, got %v", gateway.created)
+	}
+	if len(gateway.cancelled) != 1 || gateway.cancelled[0] != "e3" {
+		t.Errorf("expected event of the deleted reservation cancelled, got %v", gateway.cancelled)
+	}
+	if len(report.Orphaned) != 1 || report.Orphaned[0] != "e4" || len(report.Unmatched) != 1 || report.Unmatched[0] != "e5" {
+		t.Errorf("unexpected orphaned %v and unmatched %v events", report.Orphaned, report.Unmatched)
+	}
+	if tokens.tokens[testCalendarID] != "token-1" {
+		t.Errorf("sync token should be saved")
+	}
+}
+
+func TestConflictPolicies(t *testing.T) {
+	testCases := []struct {
+		desc              string
+		policy            ConflictPolicy
+		change            *cal.Event
+		expectedCreated   int
+		expectedUpdated   int
+		expectedCancelled int
+		expectedRestored  int
+		expectedDates     [2]string
+	}{
+		{
+			desc:            "database wins on date change",
+			policy:          DatabaseWins,
+			change:          reservationEvent("e1", "r1", "2020-10-06", "2020-10-09"),
+			expectedUpdated: 1,
+		},
+		{
+			desc:          "calendar wins on date change",
+			policy:        CalendarWins,
+			change:        reservationEvent("e1", "r1", "2020-10-06", "2020-10-09"),
+			expectedDates: [2]string{"2020-10-06", "2020-10-09"},
+		},
+		{
+			desc:          "calendar wins on timed event",
+			policy:        CalendarWins,
+			change:        timedReservationEvent("e1", "r1", "2020-10-06T14:00:00+02:00", "2020-10-09T10:00:00+02:00"),
+			expectedDates: [2]string{"2020-10-06", "2020-10-09"},
+		},
+		{
+			desc:            "database wins on deleted event",
+			policy:          DatabaseWins,
+			change:          &cal.Event{Id: "e1", Status: "cancelled"},
+			expectedCreated: 1,
+		},
+		{
+			desc:             "database wins on cancelled event",
+			policy:           DatabaseWins,
+			change:           markedCancelled(reservationEvent("e1", "r1", "2020-10-05", "2020-10-08")),
+			expectedRestored: 1,
+		},
+		{
+			desc:              "calendar wins on cancelled event",
+			policy:            CalendarWins,
+			change:            markedCancelled(reservationEvent("e1", "r1", "2020-10-05", "2020-10-08")),
+			expectedCancelled: 1,
+		},
+		{
+			desc:              "calendar wins on deleted event",
+			policy:            CalendarWins,
+			change:            &cal.Event{Id: "e1", Status: "cancelled"},
+			expectedCancelled: 1,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			store := &memoryReservationStore{
+				reservations: []dynamo.ReservationModel{reservation("r1", "2020-10-05", "2020-10-08", false)},
+				updated:      map[string][2]string{},
+			}
+			cancelled := reservationEvent("e1", "r1", "2020-10-05", "2020-10-08")
+			cancelled.Status = "cancelled"
+			gateway := &fakeGateway{
+				changes:       []*cal.Event{tC.change},
+				events:        map[string]*cal.Event{"e1": cancelled},
+				nextSyncToken: "token-2",
+			}
+			engine, _ := newTestEngine(store, gateway, "token-1")
+			engine.Policy = tC.policy
+
+			report, err := engine.Sync("A1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if report.Conflicts != 1 || len(gateway.created) != tC.expectedCreated || len(gateway.updated) != tC.expectedUpdated ||
+				len(store.cancelled) != tC.expectedCancelled || len(gateway.restored) != tC.expectedRestored ||
+				store.updated["r1"] != tC.expectedDates {
+				t.Errorf("unexpected result: %s, created: %v, updated: %v, cancelled: %v, dates: %v",
+					report, gateway.created, gateway.updated, store.cancelled, store.updated)
+			}
+		})
+	}
+}
+
+func TestTimedEventInSync(t *testing.T) {
+	store := &memoryReservationStore{reservations: []dynamo.ReservationModel{
+		reservation("r1", "2020-10-05", "2020-10-08", false),
+	}}
+	gateway := &fakeGateway{
+		changes:       []*cal.Event{timedReservationEvent("e1", "r1", "2020-10-05T14:00:00+02:00", "2020-10-08T10:00:00+02:00")},
+		nextSyncToken: "token-2",
+	}
+	engine, _ := newTestEngine(store, gateway, "token-1")
+
+	report, err := engine.Sync("A1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.InSync != 1 || report.Conflicts != 0 || len(gateway.updated) != 0 || report.Failed() {
+		t.Errorf("timed event with the reservation days should be in sync: %s", report)
+	}
+}
+
+func TestDeletedEventWithReplacement(t *testing.T) {
+	store := &memoryReservationStore{reservations: []dynamo.ReservationModel{
+		reservation("r1", "2020-10-05", "2020-10-08", false),
+	}}
+	deleted := reservationEvent("e1", "r1", "2020-10-05", "2020-10-08")
+	deleted.Status = "cancelled"
+	gateway := &fakeGateway{
+		changes: []*cal.Event{{Id: "e1", Status: "cancelled"}},
+		events: map[string]*cal.Event{
+			"e1": deleted,
+			"e2": reservationEvent("e2", "r1", "2020-10-05", "2020-10-08"),
+		},
+		nextSyncToken: "token-2",
+	}
+	engine, _ := newTestEngine(store, gateway, "token-1")
+
+	report, err := engine.Sync("A1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(gateway.created) != 0 || report.InSync != 1 {
+		t.Errorf("existing event should be kept instead of creating a duplicate, created: %v, %s", gateway.created, report)
+	}
+}
+
+func TestIncrementalSyncLooksUpUnchangedReservations(t *testing.T) {
+	store := &memoryReservationStore{reservations: []dynamo.ReservationModel{
+		reservation("moved", "2020-10-06", "2020-10-09", false),
+		reservation("deleted", "2020-10-15", "2020-10-17", true),
+		reservation("already-cancelled", "2020-10-20", "2020-10-22", true),
+		reservation("rebooked", "2020-10-25", "2020-10-27", false),
+	}}
+	gateway := &fakeGateway{
+		nextSyncToken: "token-2",
+		events: map[string]*cal.Event{
+			"e1": reservationEvent("e1", "moved", "2020-10-05", "2020-10-08"),
+			"e2": reservationEvent("e2", "deleted", "2020-10-15", "2020-10-17"),
+			"e3": markedCancelled(reservationEvent("e3", "already-cancelled", "2020-10-20", "2020-10-22")),
+			"e4": markedCancelled(reservationEvent("e4", "rebooked", "2020-10-25", "2020-10-27")),
+		},
+	}
+	engine, _ := newTestEngine(store, gateway, "token-1")
+
+	report, err := engine.Sync("A1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.FullSync || report.Conflicts != 0 {
+		t.Errorf("unexpected report: %s", report)
+	}
+	if len(gateway.updated) != 1 || len(gateway.cancelled) != 1 || gateway.cancelled[0] != "e2" {
+		t.Errorf("unexpected actions, updated: %v, cancelled: %v", gateway.updated, gateway.cancelled)
+	}
+	if len(gateway.restored) != 1 || gateway.restored[0] != "e4" || report.InSync != 1 {
+		t.Errorf("expected the event of the active reservation restored, restored: %v, %s", gateway.restored, report)
+	}
+}
+
+func TestExpiredSyncTokenAndFailures(t *testing.T) {
+	store := &memoryReservationStore{reservations: []dynamo.ReservationModel{
+		reservation("missing-event", "2020-10-10", "2020-10-12", false),
+	}}
+	gateway := &fakeGateway{expiredToken: "token-1", nextSyncToken: "token-2", failOnCreation: true}
+	engine, tokens := newTestEngine(store, gateway, "token-1")
+
+	report, err := engine.Sync("A1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(gateway.listedTokens) != 2 || gateway.listedTokens[1] != "" || !report.FullSync {
+		t.Errorf("expected full sync after the expired token, listed: %v", gateway.listedTokens)
+	}
+	if !report.Failed() || tokens.tokens[testCalendarID] != "token-1" {
+		t.Errorf("sync token should not be advanced after failures: %s", report)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	store := &memoryReservationStore{reservations: []dynamo.ReservationModel{
+		reservation("missing-event", "2020-10-10", "2020-10-12", false),
+	}}
+	gateway := &fakeGateway{nextSyncToken: "token-1"}
+	engine, tokens := newTestEngine(store, gateway, "")
+	engine.DryRun = true
+
+	report, err := engine.Sync("A1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Actions) != 1 || len(gateway.created) != 0 || tokens.tokens[testCalendarID] != "" {
+		t.Errorf("dry run should only report: %s", report)
+	}
+}
@@ -0,0 +1,82 @@
+package calendarsync
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ActionType is the change applied by the sync
+type ActionType string
+
+// Actions applied on the calendar and on the database
+const (
+	CreateEvent       ActionType = "CREATE_EVENT"
+	UpdateEvent       ActionType = "UPDATE_EVENT"
+	CancelEvent       ActionType = "CANCEL_EVENT"
+	RestoreEvent      ActionType = "RESTORE_EVENT"
+	UpdateReservation ActionType = "UPDATE_RESERVATION"
+	CancelReservation ActionType = "CANCEL_RESERVATION"
+)
+
+// Action is a change applied, or only planned in dry run
+type Action struct {
+	Type          ActionType `json:"type"`
+	ReservationID string     `json:"reservationId"`
+	EventID       string     `json:"eventId,omitempty"`
+	Detail        string     `json:"detail,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// Report is the reconciliation report of an apartment
+type Report struct {
+	ApartmentCode string `json:"apartmentCode"`
+	CalendarID    string `json:"calendarId"`
+	FullSync      bool   `json:"fullSync"`
+	DryRun        bool   `json:"dryRun"`
+	EventsPulled  int    `json:"eventsPulled"`
+	InSync        int    `json:"inSync"`
+	// Conflicts counts the reservations changed on both sides, resolved by the conflict policy
+	Conflicts int      `json:"conflicts"`
+	Actions   []Action `json:"actions"`
+	// Unmatched holds the IDs of the events without a reservation, e.g. blocks added by the staff
+	Unmatched []string `json:"unmatched"`
+	// Orphaned holds the IDs of the events whose reservation is not in the database
+	Orphaned []string `json:"orphaned"`
+	Errors   []string `json:"errors"`
+}
+
+// Failed returns true if any action or lookup failed
+func (report *Report) Failed() bool {
+	return len(report.Errors) > 0
+}
+
+func (report *Report) addAction(action Action, err error) {
+	if err != nil {
+		action.Error = err.Error()
+		report.addError(fmt.Errorf("%s %s: %v", action.Type, action.ReservationID, err))
+	}
+
+	report.Actions = append(report.Actions, action)
+}
+
+func (report *Report) addError(err error) {
+	report.Errors = append(report.Errors, err.Error())
+}
+
+func (report *Report) String() string {
+	counts := map[ActionType]int{}
+	for _, action := range report.Actions {
+		counts[action.Type]++
+	}
+
+	var parts []string
+	for _, actionType := range []ActionType{CreateEvent, UpdateEvent, CancelEvent, RestoreEvent, UpdateReservation, CancelReservation} {
+		if counts[actionType] > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", actionType, counts[actionType]))
+		}
+	}
+
+	return fmt.Sprintf("apartment: %s, full sync: %v, pulled: %d, in sync: %d, conflicts: %d, actions: [%s], unmatched: %d, orphaned: %d, errors: %d",
+		report.ApartmentCode, report.FullSync, report.EventsPulled, report.InSync, report.Conflicts,
+		strings.Join(parts, ", "), len(report.Unmatched), len(report.Orphaned), len(report.Errors))
+}
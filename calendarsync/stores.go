@@ -0,0 +1,202 @@
+package calendarsync
+
+import (
+	"log"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+	cal "google.golang.org/api/calendar/v3"
+
+	"github.com/sylank/lavender-commons-go/calendar"
+	"github.com/sylank/lavender-commons-go/dynamo"
+	"github.com/sylank/lavender-commons-go/outbox"
+)
+
+// CalendarCancellationType is the deletion type of the reservations cancelled from the calendar
+const CalendarCancellationType = "CALENDAR"
+
+// ErrNoOutbox is returned by DynamoReservationStore when its Outbox is not set
+var ErrNoOutbox = errors.New("no outbox writer configured")
+
+// ReservationStore is the database side of the sync
+type ReservationStore interface {
+	ListReservations(apartmentCode string) ([]dynamo.ReservationModel, error)
+	UpdateReservationDates(reservation *dynamo.ReservationModel, fromDate string, toDate string) error
+	CancelReservation(reservation *dynamo.ReservationModel, reason string) error
+	GuestName(userID string) (string, error)
+}
+
+// CalendarGateway is the Google Calendar side of the sync
+type CalendarGateway interface {
+	CalendarID(apartmentCode string) (string, error)
+	ListChanges(calendarID string, syncToken string) (*calendar.ChangeSet, error)
+	GetEvent(calendarID string, eventID string) (*cal.Event, error)
+	FindReservationEvent(calendarID string, reservationID string) (*cal.Event, error)
+	CreateReservationEvent(reservation *dynamo.ReservationModel, guestName string) (*cal.Event, error)
+	UpdateReservationEvent(reservation *dynamo.ReservationModel, guestName string) (*cal.Event, error)
+	CancelEvent(calendarID string, eventID string, reason string) error
+	RestoreEvent(calendarID string, eventID string) error
+}
+
+// SyncTokenStore keeps the last sync token per calendar, an empty token means a full sync
+type SyncTokenStore interface {
+	LoadSyncToken(calendarID string) (string, error)
+	SaveSyncToken(calendarID string, syncToken string) error
+}
+
+// DynamoReservationStore reads the reservations with the dynamo package and writes them through the outbox,
+// so the changes made from the calendar publish their events like every other change
+type DynamoReservationStore struct {
+	Table string
+	// DeletionTable stores the deletion data of the cancelled reservations, skipped when empty
+	DeletionTable string
+	Outbox        *outbox.Writer
+}
+
+// ListReservations ...
+func (store *DynamoReservationStore) ListReservations(apartmentCode string) ([]dynamo.ReservationModel, error) {
+	return dynamo.QueryReservationsByApartment(apartmentCode, store.Table)
+}
+
+// UpdateReservationDates stores the dates with a ReservationDatesChanged event
+func (store *DynamoReservationStore) UpdateReservationDates(reservation *dynamo.ReservationModel, fromDate string, toDate string) error {
+	if store.Outbox == nil {
+		return ErrNoOutbox
+	}
+
+	return store.Outbox.UpdateReservationDates(reservation, fromDate, toDate, store.Table)
+}
+
+// CancelReservation marks the reservation deleted with a ReservationCancelled event
+func (store *DynamoReservationStore) CancelReservation(reservation *dynamo.ReservationModel, reason string) error {
+	if store.Outbox == nil {
+		return ErrNoOutbox
+	}
+
+	var deletion *dynamo.DeletionInsertModel
+	if store.DeletionTable != "" {
+		deletion = &dynamo.DeletionInsertModel{
+			UserID:        reservation.UserID,
+			ReservationID: reservation.ReservationID,
+			Type:          CalendarCancellationType,
+			Message:       reason,
+		}
+	}
+
+	return store.Outbox.CancelReservation(reservation, deletion, store.Table, store.DeletionTable)
+}
+
+// GuestName returns the full name of the user, empty when the user data was cleared
+func (store *DynamoReservationStore) GuestName(userID string) (string, error) {
+	user, err := dynamo.QueryUserByUserID(userID)
+	if err != nil || user == nil {
+		return "", err
+	}
+
+	return user.FullName, nil
+}
+
+// GoogleCalendarGateway calls Google Calendar with the calendar package
+type GoogleCalendarGateway struct{}
+
+// CalendarID ...
+func (gateway *GoogleCalendarGateway) CalendarID(apartmentCode string) (string, error) {
+	return calendar.CalendarIDForApartment(apartmentCode)
+}
+
+// ListChanges ...
+func (gateway *GoogleCalendarGateway) ListChanges(calendarID string, syncToken string) (*calendar.ChangeSet, error) {
+	return calendar.ListChanges(calendarID, syncToken)
+}
+
+// GetEvent ...
+func (gateway *GoogleCalendarGateway) GetEvent(calendarID string, eventID string) (*cal.Event, error) {
+	return calendar.GetEvent(calendarID, eventID)
+}
+
+// FindReservationEvent ...
+func (gateway *GoogleCalendarGateway) FindReservationEvent(calendarID string, reservationID string) (*cal.Event, error) {
+	return calendar.FindReservationEvent(calendarID, reservationID)
+}
+
+// CreateReservationEvent ...
+func (gateway *GoogleCalendarGateway) CreateReservationEvent(reservation *dynamo.ReservationModel, guestName string) (*cal.Event, error) {
+	return calendar.CreateReservationEvent(reservation, guestName)
+}
+
+// UpdateReservationEvent ...
+func (gateway *GoogleCalendarGateway) UpdateReservationEvent(reservation *dynamo.ReservationModel, guestName string) (*cal.Event, error) {
+	return calendar.UpdateReservationEvent(reservation, guestName)
+}
+
+// CancelEvent ...
+func (gateway *GoogleCalendarGateway) CancelEvent(calendarID string, eventID string, reason string) error {
+	_, err := calendar.CancelEventByID(calendarID, eventID, reason)
+	return err
+}
+
+// RestoreEvent ...
+func (gateway *GoogleCalendarGateway) RestoreEvent(calendarID string, eventID string) error {
+	_, err := calendar.RestoreEventByID(calendarID, eventID)
+	return err
+}
+
+// DynamoSyncTokenStore keeps the sync tokens in a DynamoDB table keyed by CalendarId
+type DynamoSyncTokenStore struct {
+	svc   dynamodbiface.DynamoDBAPI
+	table string
+}
+
+// NewDynamoSyncTokenStore ...
+func NewDynamoSyncTokenStore(svc dynamodbiface.DynamoDBAPI, table string) *DynamoSyncTokenStore {
+	return &DynamoSyncTokenStore{
+		svc:   svc,
+		table: table,
+	}
+}
+
+// LoadSyncToken ...
+func (store *DynamoSyncTokenStore) LoadSyncToken(calendarID string) (string, error) {
+	result, err := store.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(store.table),
+		Key: map[string]*dynamodb.AttributeValue{
+			"CalendarId": {
+				S: aws.String(calendarID),
+			},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		log.Println("Unable to load sync token, calendarId: "+calendarID, err)
+		return "", err
+	}
+
+	if syncToken, ok := result.Item["SyncToken"]; ok {
+		return aws.StringValue(syncToken.S), nil
+	}
+
+	return "", nil
+}
+
+// SaveSyncToken ...
+func (store *DynamoSyncTokenStore) SaveSyncToken(calendarID string, syncToken string) error {
+	_, err := store.svc.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(store.table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"CalendarId": {
+				S: aws.String(calendarID),
+			},
+			"SyncToken": {
+				S: aws.String(syncToken),
+			},
+		},
+	})
+	if err != nil {
+		log.Println("Unable to save sync token, calendarId: "+calendarID, err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,302 @@
+package calendarsync
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
+	"github.com/sylank/lavender-commons-go/calendar"
+)
+
+// Defaults of the WatchManager
+const (
+	DefaultChannelTTL  = 7 * 24 * time.Hour
+	DefaultRenewBefore = 24 * time.Hour
+)
+
+// Channel is a push notification channel of an apartment calendar
+type Channel struct {
+	ChannelID     string    `dynamodbav:"ChannelId" json:"channelId"`
+	ResourceID    string    `dynamodbav:"ResourceId" json:"resourceId"`
+	CalendarID    string    `dynamodbav:"CalendarId" json:"calendarId"`
+	ApartmentCode string    `dynamodbav:"ApartmentCode" json:"apartmentCode"`
+	Token         string    `dynamodbav:"Token" json:"-"`
+	Expiration    time.Time `dynamodbav:"Expiration" json:"expiration"`
+}
+
+// ChannelStore keeps the active channels, LoadChannel returns nil for unknown channels
+type ChannelStore interface {
+	SaveChannel(channel *Channel) error
+	LoadChannel(channelID string) (*Channel, error)
+	ListChannels() ([]*Channel, error)
+	DeleteChannel(channelID string) error
+}
+
+// WatchGateway registers and stops the channels on Google Calendar
+type WatchGateway interface {
+	CalendarID(apartmentCode string) (string, error)
+	Watch(calendarID string, channelID string, token string, address string, ttl time.Duration) (*Channel, error)
+	Stop(channel *Channel) error
+}
+
+// Watch ...
+func (gateway *GoogleCalendarGateway) Watch(calendarID string, channelID string, token string, address string, ttl time.Duration) (*Channel, error) {
+	created, err := calendar.WatchEvents(calendarID, channelID, address, token, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Channel{
+		ChannelID:  created.Id,
+		ResourceID: created.ResourceId,
+		CalendarID: calendarID,
+		Token:      token,
+		Expiration: calendar.ChannelExpiration(created),
+	}, nil
+}
+
+// Stop ...
+func (gateway *GoogleCalendarGateway) Stop(channel *Channel) error {
+	return calendar.StopChannel(channel.ChannelID, channel.ResourceID)
+}
+
+// WatchManager keeps a channel open for every apartment calendar, channels are replaced RenewBefore their expiry
+type WatchManager struct {
+	// Address is the HTTPS URL of the WebhookHandler
+	Address     string
+	TTL         time.Duration
+	RenewBefore time.Duration
+
+	store   ChannelStore
+	gateway WatchGateway
+	now     func() time.Time
+}
+
+// NewWatchManager ...
+func NewWatchManager(store ChannelStore, gateway WatchGateway, address string) *WatchManager {
+	return &WatchManager{
+		Address:     address,
+		TTL:         DefaultChannelTTL,
+		RenewBefore: DefaultRenewBefore,
+		store:       store,
+		gateway:     gateway,
+		now:         time.Now,
+	}
+}
+
+// EnsureWatch opens a channel for the apartment unless a channel is open far enough from its expiry,
+// the replaced channels are stopped
+func (manager *WatchManager) EnsureWatch(apartmentCode string) (*Channel, error) {
+	channels, err := manager.store.ListChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	var current *Channel
+	var stale []*Channel
+	renewAt := manager.now().Add(manager.RenewBefore)
+	for _, channel := range channels {
+		if channel.ApartmentCode != apartmentCode {
+			continue
+		}
+		if current == nil && channel.Expiration.After(renewAt) {
+			current = channel
+			continue
+		}
+		stale = append(stale, channel)
+	}
+
+	if current == nil {
+		current, err = manager.openChannel(apartmentCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, channel := range stale {
+		manager.stopChannel(channel)
+	}
+
+	return current, nil
+}
+
+// EnsureWatchAll calls EnsureWatch for every apartment, a failing apartment does not stop the others
+func (manager *WatchManager) EnsureWatchAll(apartmentCodes []string) error {
+	var lastErr error
+	for _, apartmentCode := range apartmentCodes {
+		if _, err := manager.EnsureWatch(apartmentCode); err != nil {
+			log.Println("Unable to watch apartment: "+apartmentCode, err)
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// StopAll stops every channel of the store
+func (manager *WatchManager) StopAll() error {
+	channels, err := manager.store.ListChannels()
+	if err != nil {
+		return err
+	}
+
+	for _, channel := range channels {
+		manager.stopChannel(channel)
+	}
+
+	return nil
+}
+
+func (manager *WatchManager) openChannel(apartmentCode string) (*Channel, error) {
+	calendarID, err := manager.gateway.CalendarID(apartmentCode)
+	if err != nil {
+		return nil, err
+	}
+
+	channelID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := manager.gateway.Watch(calendarID, channelID, token, manager.Address, manager.TTL)
+	if err != nil {
+		return nil, err
+	}
+	channel.ApartmentCode = apartmentCode
+
+	if err := manager.store.SaveChannel(channel); err != nil {
+		// An unsaved channel would be rejected by the webhook, so it is not left open
+		manager.gateway.Stop(channel)
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+// stopChannel stops and forgets the channel, failing to stop is only logged as the channel expires anyway
+func (manager *WatchManager) stopChannel(channel *Channel) {
+	if err := manager.gateway.Stop(channel); err != nil {
+		log.Println("Unable to stop channel, channelId: "+channel.ChannelID, err)
+	}
+	if err := manager.store.DeleteChannel(channel.ChannelID); err != nil {
+		log.Println("Unable to delete channel, channelId: "+channel.ChannelID, err)
+	}
+}
+
+func randomHex(length int) (string, error) {
+	data := make([]byte, length)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+// DynamoChannelStore keeps the channels in a DynamoDB table keyed by ChannelId
+type DynamoChannelStore struct {
+	svc   dynamodbiface.DynamoDBAPI
+	table string
+}
+
+// NewDynamoChannelStore ...
+func NewDynamoChannelStore(svc dynamodbiface.DynamoDBAPI, table string) *DynamoChannelStore {
+	return &DynamoChannelStore{
+		svc:   svc,
+		table: table,
+	}
+}
+
+// SaveChannel ...
+func (store *DynamoChannelStore) SaveChannel(channel *Channel) error {
+	av, err := dynamodbattribute.MarshalMap(channel)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.svc.PutItem(&dynamodb.PutItemInput{
+		Item:      av,
+		TableName: aws.String(store.table),
+	})
+	if err != nil {
+		log.Println("Unable to save channel, channelId: "+channel.ChannelID, err)
+		return err
+	}
+
+	return nil
+}
+
+// LoadChannel ...
+func (store *DynamoChannelStore) LoadChannel(channelID string) (*Channel, error) {
+	result, err := store.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(store.table),
+		Key:       channelKey(channelID),
+	})
+	if err != nil {
+		log.Println("Unable to load channel, channelId: "+channelID, err)
+		return nil, err
+	}
+	if len(result.Item) == 0 {
+		return nil, nil
+	}
+
+	channel := &Channel{}
+	err = dynamodbattribute.UnmarshalMap(result.Item, channel)
+
+	return channel, err
+}
+
+// ListChannels ...
+func (store *DynamoChannelStore) ListChannels() ([]*Channel, error) {
+	channels := []*Channel{}
+	err := store.svc.ScanPages(&dynamodb.ScanInput{
+		TableName: aws.String(store.table),
+	}, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			channel := &Channel{}
+			if err := dynamodbattribute.UnmarshalMap(item, channel); err != nil {
+				log.Println("Unable to unmarshal channel", err)
+				continue
+			}
+			channels = append(channels, channel)
+		}
+		return true
+	})
+	if err != nil {
+		log.Println("Unable to list channels", err)
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+// DeleteChannel ...
+func (store *DynamoChannelStore) DeleteChannel(channelID string) error {
+	_, err := store.svc.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(store.table),
+		Key:       channelKey(channelID),
+	})
+	if err != nil {
+		log.Println("Unable to delete channel, channelId: "+channelID, err)
+		return err
+	}
+
+	return nil
+}
+
+func channelKey(channelID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"ChannelId": {
+			S: aws.String(channelID),
+		},
+	}
+}
@@ -0,0 +1,235 @@
+package calendarsync
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type memoryChannelStore struct {
+	channels map[string]*Channel
+}
+
+func (store *memoryChannelStore) SaveChannel(channel *Channel) error {
+	store.channels[channel.ChannelID] = channel
+	return nil
+}
+
+func (store *memoryChannelStore) LoadChannel(channelID string) (*Channel, error) {
+	return store.channels[channelID], nil
+}
+
+func (store *memoryChannelStore) ListChannels() ([]*Channel, error) {
+	var channels []*Channel
+	for _, channel := range store.channels {
+		channels = append(channels, channel)
+	}
+
+	return channels, nil
+}
+
+func (store *memoryChannelStore) DeleteChannel(channelID string) error {
+	delete(store.channels, channelID)
+	return nil
+}
+
+type fakeWatchGateway struct {
+	now     time.Time
+	watched []string
+	stopped []string
+}
+
+func (gateway *fakeWatchGateway) CalendarID(apartmentCode string) (string, error) {
+	return apartmentCode + "@group.calendar.google.com", nil
+}
+
+func (gateway *fakeWatchGateway) Watch(calendarID string, channelID string, token string, address string, ttl time.Duration) (*Channel, error) {
+	gateway.watched = append(gateway.watched, calendarID)
+
+	return &Channel{
+		ChannelID:  channelID,
+		ResourceID: "resource-" + calendarID,
+		CalendarID: calendarID,
+		Token:      token,
+		Expiration: gateway.now.Add(ttl),
+	}, nil
+}
+
+func (gateway *fakeWatchGateway) Stop(channel *Channel) error {
+	gateway.stopped = append(gateway.stopped, channel.ChannelID)
+	return nil
+}
+
+func TestWatchManagerRenewsChannels(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	store := &memoryChannelStore{channels: map[string]*Channel{
+		"fresh":    {ChannelID: "fresh", ApartmentCode: "A1", Expiration: now.Add(3 * 24 * time.Hour)},
+		"expiring": {ChannelID: "expiring", ApartmentCode: "B2", Expiration: now.Add(time.Hour)},
+	}}
+	gateway := &fakeWatchGateway{now: now}
+	manager := NewWatchManager(store, gateway, "https://example.com/calendar/webhook")
+	manager.now = func() time.Time {
+		return now
+	}
+
+	if err := manager.EnsureWatchAll([]string{"A1", "B2", "C3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(gateway.watched) != 2 || gateway.watched[0] != "B2@group.calendar.google.com" || gateway.watched[1] != "C3@group.calendar.google.com" {
+		t.Errorf("expected new channels for B2 and C3, watched: %v", gateway.watched)
+	}
+	if len(gateway.stopped) != 1 || gateway.stopped[0] != "expiring" {
+		t.Errorf("expected the expiring channel stopped, stopped: %v", gateway.stopped)
+	}
+	if len(store.channels) != 3 || store.channels["fresh"] == nil {
+		t.Errorf("unexpected channels: %v", store.channels)
+	}
+	for _, channel := range store.channels {
+		if channel.ChannelID != "fresh" && (channel.Token == "" || !channel.Expiration.Equal(now.Add(DefaultChannelTTL))) {
+			t.Errorf("unexpected channel: %+v", channel)
+		}
+	}
+}
+
+func TestWebhookHandler(t *testing.T) {
+	store := &memoryChannelStore{channels: map[string]*Channel{
+		"channel-1": {ChannelID: "channel-1", ResourceID: "resource-1", ApartmentCode: "A1", Token: "secret"},
+	}}
+
+	headers := func(state string, token string) map[string]string {
+		return map[string]string{
+			ChannelIDHeader:     "channel-1",
+			ChannelTokenHeader:  token,
+			ResourceIDHeader:    "resource-1",
+			ResourceStateHeader: state,
+			MessageNumberHeader: "2",
+		}
+	}
+
+	testCases := []struct {
+		desc           string
+		method         string
+		headers        map[string]string
+		syncErr        error
+		expectedStatus int
+		expectedSyncs  int
+	}{
+		{
+			desc:           "change triggers sync",
+			method:         http.MethodPost,
+			headers:        headers(ResourceStateExists, "secret"),
+			expectedStatus: http.StatusOK,
+			expectedSyncs:  1,
+		},
+		{
+			desc:           "sync message is acknowledged",
+			method:         http.MethodPost,
+			headers:        headers(ResourceStateSync, "secret"),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "wrong token",
+			method:         http.MethodPost,
+			headers:        headers(ResourceStateExists, "guess"),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			desc:           "unknown channel",
+			method:         http.MethodPost,
+			headers:        map[string]string{ChannelIDHeader: "channel-2", ResourceStateHeader: ResourceStateExists},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			desc:           "missing headers",
+			method:         http.MethodPost,
+			headers:        map[string]string{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			desc:           "wrong method",
+			method:         http.MethodGet,
+			headers:        headers(ResourceStateExists, "secret"),
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			desc:           "failed sync is acknowledged",
+			method:         http.MethodPost,
+			headers:        headers(ResourceStateExists, "secret"),
+			syncErr:        errors.New("calendar unavailable"),
+			expectedStatus: http.StatusOK,
+			expectedSyncs:  1,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var synced []string
+			handler := NewWebhookHandler(store, func(apartmentCode string) error {
+				synced = append(synced, apartmentCode)
+				return tC.syncErr
+			})
+
+			request := httptest.NewRequest(tC.method, "/calendar/webhook", nil)
+			for name, value := range tC.headers {
+				request.Header.Set(name, value)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			handler.Wait()
+
+			if recorder.Code != tC.expectedStatus {
+				t.Errorf("expected status %d, got %d", tC.expectedStatus, recorder.Code)
+			}
+			if len(synced) != tC.expectedSyncs || (tC.expectedSyncs > 0 && synced[0] != "A1") {
+				t.Errorf("unexpected syncs: %v", synced)
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerCoalescesNotifications(t *testing.T) {
+	store := &memoryChannelStore{channels: map[string]*Channel{
+		"channel-1": {ChannelID: "channel-1", ResourceID: "resource-1", ApartmentCode: "A1", Token: "secret"},
+	}}
+
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	var syncs int32
+	handler := NewWebhookHandler(store, func(apartmentCode string) error {
+		atomic.AddInt32(&syncs, 1)
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+
+	notify := func() int {
+		request := httptest.NewRequest(http.MethodPost, "/calendar/webhook", nil)
+		request.Header.Set(ChannelIDHeader, "channel-1")
+		request.Header.Set(ChannelTokenHeader, "secret")
+		request.Header.Set(ResourceIDHeader, "resource-1")
+		request.Header.Set(ResourceStateHeader, ResourceStateExists)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		return recorder.Code
+	}
+
+	if status := notify(); status != http.StatusOK {
+		t.Fatalf("expected status 200 before the sync finished, got %d", status)
+	}
+	<-started
+	for i := 0; i < 3; i++ {
+		if status := notify(); status != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", status)
+		}
+	}
+	close(release)
+	handler.Wait()
+
+	if count := atomic.LoadInt32(&syncs); count != 2 {
+		t.Errorf("notifications during the sync should be coalesced into one sync, got %d syncs", count)
+	}
+}
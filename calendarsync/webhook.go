@@ -0,0 +1,142 @@
+package calendarsync
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// Headers of the Google Calendar push notifications
+const (
+	ChannelIDHeader     = "X-Goog-Channel-ID"
+	ChannelTokenHeader  = "X-Goog-Channel-Token"
+	ResourceIDHeader    = "X-Goog-Resource-ID"
+	ResourceStateHeader = "X-Goog-Resource-State"
+	MessageNumberHeader = "X-Goog-Message-Number"
+)
+
+// Resource states of the push notifications, sync is sent once when the channel is opened
+const (
+	ResourceStateSync      = "sync"
+	ResourceStateExists    = "exists"
+	ResourceStateNotExists = "not_exists"
+)
+
+// SyncFunc is triggered with the apartment of the changed calendar
+type SyncFunc func(apartmentCode string) error
+
+// EngineSyncFunc runs an incremental sync of the engine
+func EngineSyncFunc(engine *Engine) SyncFunc {
+	return func(apartmentCode string) error {
+		_, err := engine.Sync(apartmentCode)
+		return err
+	}
+}
+
+// WebhookHandler receives the push notifications of the channels in the store and triggers a sync. Notifications
+// of unknown channels or with a wrong token are rejected, valid ones are acknowledged right away and the sync
+// runs in the background, so the handler needs a long running process. Notifications arriving while the
+// apartment is syncing are coalesced into one more sync. A failed sync does not advance the sync token, the
+// changes are pulled again by the next sync.
+type WebhookHandler struct {
+	store ChannelStore
+	sync  SyncFunc
+
+	lock    sync.Mutex
+	syncing map[string]bool
+	pending map[string]bool
+	running sync.WaitGroup
+}
+
+// NewWebhookHandler ...
+func NewWebhookHandler(store ChannelStore, sync SyncFunc) *WebhookHandler {
+	return &WebhookHandler{
+		store:   store,
+		sync:    sync,
+		syncing: map[string]bool{},
+		pending: map[string]bool{},
+	}
+}
+
+func (handler *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	channelID := r.Header.Get(ChannelIDHeader)
+	resourceState := r.Header.Get(ResourceStateHeader)
+	if channelID == "" || resourceState == "" {
+		http.Error(w, "missing channel headers", http.StatusBadRequest)
+		return
+	}
+
+	channel, err := handler.store.LoadChannel(channelID)
+	if err != nil {
+		http.Error(w, "unable to load channel", http.StatusInternalServerError)
+		return
+	}
+	if channel == nil || !validToken(channel.Token, r.Header.Get(ChannelTokenHeader)) ||
+		channel.ResourceID != r.Header.Get(ResourceIDHeader) {
+		log.Println("Rejected notification, channelId: " + channelID)
+		http.Error(w, "unknown channel", http.StatusForbidden)
+		return
+	}
+
+	switch resourceState {
+	case ResourceStateSync:
+		log.Println("Channel opened, channelId: " + channelID)
+	case ResourceStateExists, ResourceStateNotExists:
+		log.Println("Calendar changed, apartment: " + channel.ApartmentCode + " message: " + r.Header.Get(MessageNumberHeader))
+		handler.trigger(channel.ApartmentCode)
+	default:
+		log.Println("Ignored resource state: " + resourceState)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Wait blocks until the triggered syncs finished, e.g. before shutting down
+func (handler *WebhookHandler) Wait() {
+	handler.running.Wait()
+}
+
+// trigger starts a sync of the apartment unless one is running, then one more sync follows it
+func (handler *WebhookHandler) trigger(apartmentCode string) {
+	handler.lock.Lock()
+	defer handler.lock.Unlock()
+
+	if handler.syncing[apartmentCode] {
+		handler.pending[apartmentCode] = true
+		return
+	}
+	handler.syncing[apartmentCode] = true
+
+	handler.running.Add(1)
+	go handler.syncLoop(apartmentCode)
+}
+
+func (handler *WebhookHandler) syncLoop(apartmentCode string) {
+	defer handler.running.Done()
+
+	for {
+		if err := handler.sync(apartmentCode); err != nil {
+			log.Println("Sync failed, apartment: "+apartmentCode, err)
+		}
+
+		handler.lock.Lock()
+		if !handler.pending[apartmentCode] {
+			delete(handler.syncing, apartmentCode)
+			handler.lock.Unlock()
+			return
+		}
+		delete(handler.pending, apartmentCode)
+		handler.lock.Unlock()
+	}
+}
+
+func validToken(expected string, actual string) bool {
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) == 1
+}
@@ -0,0 +1,205 @@
+package circuit
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// State of the circuit breaker
+type State int
+
+// Breaker states, calls are let through when closed, rejected when open and a single trial call
+// is let through when half-open
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (state State) String() string {
+	switch state {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+
+	return "unknown"
+}
+
+// ErrOpen is the cause of every OpenError
+var ErrOpen = errors.New("circuit breaker is open")
+
+// OpenError is returned without calling the function while the breaker is open
+type OpenError struct {
+	Name string
+	// RetryAfter is the remaining cool-down, zero while a half-open trial call is in flight
+	RetryAfter time.Duration
+}
+
+func (err *OpenError) Error() string {
+	return fmt.Sprintf("%s: %s, retry after %s", ErrOpen, err.Name, err.RetryAfter)
+}
+
+// Cause makes errors.Cause return ErrOpen
+func (err *OpenError) Cause() error {
+	return ErrOpen
+}
+
+// Is makes errors.Is match ErrOpen
+func (err *OpenError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+// IsOpen returns true if the error was returned by an open breaker
+func IsOpen(err error) bool {
+	return errors.Cause(err) == ErrOpen
+}
+
+// Status is a snapshot of the breaker for health checks
+type Status struct {
+	Name                string    `json:"name"`
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	OpenedAt            time.Time `json:"openedAt,omitempty"`
+}
+
+// Breaker trips after FailureThreshold consecutive failures and half-opens after CoolDown
+type Breaker struct {
+	Name             string
+	FailureThreshold int
+	CoolDown         time.Duration
+	// IsFailure decides which errors count as failures, every error counts when nil
+	IsFailure func(err error) bool
+
+	mutex         sync.Mutex
+	state         State
+	failures      int
+	openedAt      time.Time
+	trialInFlight bool
+	now           func() time.Time
+}
+
+// NewBreaker ...
+func NewBreaker(name string, failureThreshold int, coolDown time.Duration) *Breaker {
+	return &Breaker{
+		Name:             name,
+		FailureThreshold: failureThreshold,
+		CoolDown:         coolDown,
+		now:              time.Now,
+	}
+}
+
+// Execute calls fn unless the breaker is open and records the result
+func (breaker *Breaker) Execute(fn func() error) error {
+	if err := breaker.allow(); err != nil {
+		return err
+	}
+
+	err := fn()
+	breaker.record(err)
+
+	return err
+}
+
+// State returns the current state, an open breaker whose cool-down elapsed is reported as half-open
+func (breaker *Breaker) State() State {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	return breaker.currentState()
+}
+
+// Status returns the snapshot of the breaker
+func (breaker *Breaker) Status() Status {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	return Status{
+		Name:                breaker.Name,
+		State:               breaker.currentState().String(),
+		ConsecutiveFailures: breaker.failures,
+		OpenedAt:            breaker.openedAt,
+	}
+}
+
+// Reset closes the breaker
+func (breaker *Breaker) Reset() {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	breaker.close()
+}
+
+func (breaker *Breaker) currentState() State {
+	if breaker.state == Open && breaker.clock().Sub(breaker.openedAt) >= breaker.CoolDown {
+		return HalfOpen
+	}
+
+	return breaker.state
+}
+
+func (breaker *Breaker) allow() error {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	switch breaker.currentState() {
+	case Closed:
+		return nil
+	case HalfOpen:
+		if breaker.trialInFlight {
+			return &OpenError{Name: breaker.Name}
+		}
+		log.Println("Circuit breaker half-open, trying a call: " + breaker.Name)
+		breaker.state = HalfOpen
+		breaker.trialInFlight = true
+		return nil
+	}
+
+	return &OpenError{
+		Name:       breaker.Name,
+		RetryAfter: breaker.CoolDown - breaker.clock().Sub(breaker.openedAt),
+	}
+}
+
+func (breaker *Breaker) record(err error) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if err == nil || (breaker.IsFailure != nil && !breaker.IsFailure(err)) {
+		if breaker.state != Closed {
+			log.Println("Circuit breaker closed: " + breaker.Name)
+		}
+		breaker.close()
+		return
+	}
+
+	breaker.failures++
+	if breaker.state == HalfOpen || breaker.failures >= breaker.FailureThreshold {
+		log.Printf("Circuit breaker opened after %d consecutive failures: %s %v", breaker.failures, breaker.Name, err)
+		breaker.state = Open
+		breaker.openedAt = breaker.clock()
+		breaker.trialInFlight = false
+	}
+}
+
+func (breaker *Breaker) close() {
+	breaker.state = Closed
+	breaker.failures = 0
+	breaker.openedAt = time.Time{}
+	breaker.trialInFlight = false
+}
+
+func (breaker *Breaker) clock() time.Time {
+	if breaker.now == nil {
+		return time.Now()
+	}
+
+	return breaker.now()
+}
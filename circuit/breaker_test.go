@@ -0,0 +1,128 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	return clock.now
+}
+
+func newTestBreaker(clock *fakeClock) *Breaker {
+	breaker := NewBreaker("calendar", 3, time.Minute)
+	breaker.now = clock.Now
+
+	return breaker
+}
+
+func fail() error {
+	return errUnavailable
+}
+
+func succeed() error {
+	return nil
+}
+
+func TestBreakerTrips(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)}
+	breaker := newTestBreaker(clock)
+
+	for i := 0; i < 3; i++ {
+		if err := breaker.Execute(fail); err != errUnavailable {
+			t.Fatalf("expected the error of the call, got %v", err)
+		}
+	}
+	if breaker.State() != Open {
+		t.Fatalf("expected open breaker, got %s", breaker.State())
+	}
+
+	called := false
+	err := breaker.Execute(func() error {
+		called = true
+		return nil
+	})
+	if called || !IsOpen(err) {
+		t.Fatalf("open breaker should fail fast, called: %v, err: %v", called, err)
+	}
+	if openErr, ok := err.(*OpenError); !ok || openErr.RetryAfter != time.Minute {
+		t.Errorf("unexpected open error: %v", err)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		trial    func() error
+		expected State
+	}{
+		{
+			desc:     "successful trial closes",
+			trial:    succeed,
+			expected: Closed,
+		},
+		{
+			desc:     "failed trial opens again",
+			trial:    fail,
+			expected: Open,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)}
+			breaker := newTestBreaker(clock)
+			for i := 0; i < 3; i++ {
+				breaker.Execute(fail)
+			}
+
+			clock.now = clock.now.Add(time.Minute)
+			if breaker.State() != HalfOpen {
+				t.Fatalf("expected half-open breaker, got %s", breaker.State())
+			}
+
+			breaker.Execute(func() error {
+				if err := breaker.Execute(succeed); !IsOpen(err) {
+					t.Errorf("only one trial call is allowed, got %v", err)
+				}
+				return tC.trial()
+			})
+
+			if breaker.State() != tC.expected {
+				t.Errorf("expected %s, got %s", tC.expected, breaker.State())
+			}
+		})
+	}
+}
+
+func TestBreakerIgnoresNonFailures(t *testing.T) {
+	breaker := NewBreaker("calendar", 1, time.Minute)
+	breaker.IsFailure = func(err error) bool {
+		return err == errUnavailable
+	}
+
+	notFound := errors.New("not found")
+	breaker.Execute(func() error {
+		return notFound
+	})
+	if breaker.State() != Closed {
+		t.Errorf("non failure errors should not trip the breaker")
+	}
+
+	breaker.Execute(fail)
+	status := breaker.Status()
+	if status.State != "open" || status.ConsecutiveFailures != 1 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	breaker.Reset()
+	if breaker.State() != Closed {
+		t.Errorf("reset should close the breaker")
+	}
+}
@@ -3,7 +3,10 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
 	"encoding/base64"
+	"errors"
+	"io"
 )
 
 const noncesize = 12
@@ -47,3 +50,49 @@ func DecryptString(key []byte, securemess string) (string, error) {
 	decrypted, err := aesgcm.Open(nil, nonce, []byte(ciphertext), nil)
 	return string(decrypted), err
 }
+
+// SealString encrypts the input with a random nonce stored in front of the ciphertext, unlike EncryptString
+// the result differs for every call, so it is meant for secrets that are never searched for
+func SealString(key []byte, input string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, noncesize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := aesgcm.Seal(nonce, nonce, []byte(input), nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// OpenString decrypts the output of SealString
+func OpenString(key []byte, sealed string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	if len(ciphertext) < noncesize {
+		return "", errors.New("sealed text is too short")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	decrypted, err := aesgcm.Open(nil, ciphertext[:noncesize], ciphertext[noncesize:], nil)
+	return string(decrypted), err
+}
@@ -47,3 +47,28 @@ func TestEncryptDecrypt(t *testing.T) {
 		})
 	}
 }
+
+func TestSealOpen(t *testing.T) {
+	key := []byte("12345678901234567890123456789012")
+
+	first, err := SealString(key, "refresh-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := SealString(key, "refresh-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Errorf("sealed texts should differ")
+	}
+
+	opened, err := OpenString(key, first)
+	if err != nil || opened != "refresh-token" {
+		t.Errorf("unexpected opened text: %s, err: %v", opened, err)
+	}
+
+	if _, err := OpenString([]byte("abcdefghijklmnopqrstuvwxyz123456"), first); err == nil {
+		t.Errorf("opening with a different key should fail")
+	}
+}
@@ -0,0 +1,176 @@
+package dates
+
+import (
+	"fmt"
+	"time"
+	// Embedded zone database, Lambda images do not ship one
+	_ "time/tzdata"
+)
+
+// DateLayout is the layout of the all-day dates, e.g. the FromDate and ToDate values of a reservation
+const DateLayout = "2006-01-02"
+
+// DefaultTimeZone is used for apartments without a configured time zone
+const DefaultTimeZone = "Europe/Budapest"
+
+const defaultLocale = "hu-HU"
+
+var localeLayouts = map[string]string{
+	"hu-HU": "2006. 01. 02.",
+	"en-US": "Jan 2, 2006",
+	"en-GB": "2 Jan 2006",
+	"de-DE": "02.01.2006",
+	"de-AT": "02.01.2006",
+}
+
+// Range is a half-open time range, End is exclusive. All-day ranges start and end at midnight in their location,
+// so a reservation from 2020-10-01 to 2020-10-04 ends at the start of the check-out day.
+type Range struct {
+	Start  time.Time
+	End    time.Time
+	AllDay bool
+}
+
+// LoadLocation loads the time zone, an empty name means DefaultTimeZone
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultTimeZone
+	}
+
+	return time.LoadLocation(name)
+}
+
+// ParseDate parses a YYYY-MM-DD date as midnight in the location or an RFC3339 timestamp converted to the location,
+// the returned flag is true for dates
+func ParseDate(value string, location *time.Location) (time.Time, bool, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	if parsed, err := time.ParseInLocation(DateLayout, value, location); err == nil {
+		return parsed, true, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date: %s", value)
+	}
+
+	return parsed.In(location), false, nil
+}
+
+// ParseRange parses the start and the exclusive end, the range is all-day when both are dates
+func ParseRange(from string, to string, location *time.Location) (Range, error) {
+	start, startAllDay, err := ParseDate(from, location)
+	if err != nil {
+		return Range{}, err
+	}
+	end, endAllDay, err := ParseDate(to, location)
+	if err != nil {
+		return Range{}, err
+	}
+
+	return NewRange(start, end, startAllDay && endAllDay)
+}
+
+// NewRange returns an error unless the end is after the start
+func NewRange(start time.Time, end time.Time, allDay bool) (Range, error) {
+	if !end.After(start) {
+		return Range{}, fmt.Errorf("end %s is not after start %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+
+	return Range{Start: start, End: end, AllDay: allDay}, nil
+}
+
+// Days returns the nights of the range in the location of the start: the day of the end is the check-out day,
+// so a stay from 14:00 to 10:00 three days later is three nights, a range within a single day blocks that day
+func (r Range) Days() Range {
+	if r.AllDay {
+		return r
+	}
+
+	location := r.Start.Location()
+	start := midnight(r.Start, location)
+	end := midnight(r.End.In(location), location)
+	if !end.After(start) {
+		end = start.AddDate(0, 0, 1)
+	}
+
+	return Range{Start: start, End: end, AllDay: true}
+}
+
+// Nights returns the number of nights of the whole day range
+func (r Range) Nights() int {
+	days := r.Days()
+	nights := 0
+	for day := days.Start; day.Before(days.End); day = day.AddDate(0, 0, 1) {
+		nights++
+	}
+
+	return nights
+}
+
+// StartDate returns the first day in YYYY-MM-DD format
+func (r Range) StartDate() string {
+	return r.Start.Format(DateLayout)
+}
+
+// EndDate returns the exclusive end in YYYY-MM-DD format, the check-out day of a reservation
+func (r Range) EndDate() string {
+	return r.Days().End.In(r.Start.Location()).Format(DateLayout)
+}
+
+// LastDay returns the last day inside the range, the day before EndDate
+func (r Range) LastDay() time.Time {
+	days := r.Days()
+
+	return days.End.AddDate(0, 0, -1)
+}
+
+// Overlaps returns true if the ranges share any instant, ranges touching at the end do not overlap
+func (r Range) Overlaps(other Range) bool {
+	return r.Start.Before(other.End) && other.Start.Before(r.End)
+}
+
+// Contains returns true if the instant is inside the range
+func (r Range) Contains(instant time.Time) bool {
+	return !instant.Before(r.Start) && instant.Before(r.End)
+}
+
+// In returns the range in the location, all-day ranges keep their dates
+func (r Range) In(location *time.Location) Range {
+	if !r.AllDay {
+		return Range{Start: r.Start.In(location), End: r.End.In(location)}
+	}
+
+	return Range{
+		Start:  midnight(r.Start, location),
+		End:    midnight(r.End, location),
+		AllDay: true,
+	}
+}
+
+// FormatDate formats the day for the locale, e.g. "2020. 10. 01." for hu-HU or "Oct 1, 2020" for en-US.
+// Unknown locales fall back to hu-HU.
+func FormatDate(day time.Time, locale string) string {
+	layout, ok := localeLayouts[locale]
+	if !ok {
+		layout = localeLayouts[defaultLocale]
+	}
+
+	return day.Format(layout)
+}
+
+func (r Range) String() string {
+	if r.AllDay {
+		return r.StartDate() + "/" + r.EndDate()
+	}
+
+	return r.Start.Format(time.RFC3339) + "/" + r.End.Format(time.RFC3339)
+}
+
+func midnight(instant time.Time, location *time.Location) time.Time {
+	year, month, day := instant.Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, location)
+}
@@ -0,0 +1,115 @@
+package dates
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRange(t *testing.T) {
+	budapest, err := LoadLocation("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		desc          string
+		from          string
+		to            string
+		expectedStart time.Time
+		allDay        bool
+		nights        int
+		endDate       string
+		expectErr     bool
+	}{
+		{
+			desc:          "dates",
+			from:          "2020-10-01",
+			to:            "2020-10-04",
+			expectedStart: time.Date(2020, 10, 1, 0, 0, 0, 0, budapest),
+			allDay:        true,
+			nights:        3,
+			endDate:       "2020-10-04",
+		},
+		{
+			desc:          "timestamps in another zone",
+			from:          "2020-10-01T12:00:00Z",
+			to:            "2020-10-04T08:00:00Z",
+			expectedStart: time.Date(2020, 10, 1, 14, 0, 0, 0, budapest),
+			nights:        3,
+			endDate:       "2020-10-04",
+		},
+		{
+			desc:          "over the daylight saving change",
+			from:          "2020-10-24",
+			to:            "2020-10-27",
+			expectedStart: time.Date(2020, 10, 24, 0, 0, 0, 0, budapest),
+			allDay:        true,
+			nights:        3,
+			endDate:       "2020-10-27",
+		},
+		{
+			desc:      "end before start",
+			from:      "2020-10-04",
+			to:        "2020-10-01",
+			expectErr: true,
+		},
+		{
+			desc:      "invalid date",
+			from:      "10/01/2020",
+			to:        "2020-10-04",
+			expectErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			period, err := ParseRange(tC.from, tC.to, budapest)
+			if tC.expectErr {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !period.Start.Equal(tC.expectedStart) || period.AllDay != tC.allDay {
+				t.Errorf("unexpected range: %s", period)
+			}
+			if period.Nights() != tC.nights || period.EndDate() != tC.endDate {
+				t.Errorf("expected %d nights until %s, got %d until %s", tC.nights, tC.endDate, period.Nights(), period.EndDate())
+			}
+		})
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	first, _ := ParseRange("2020-10-01", "2020-10-04", time.UTC)
+	touching, _ := ParseRange("2020-10-04", "2020-10-06", time.UTC)
+	overlapping, _ := ParseRange("2020-10-03", "2020-10-06", time.UTC)
+
+	if first.Overlaps(touching) {
+		t.Errorf("check-out day should be free for the next check-in")
+	}
+	if !first.Overlaps(overlapping) || !overlapping.Overlaps(first) {
+		t.Errorf("expected overlap")
+	}
+	if first.LastDay().Format(DateLayout) != "2020-10-03" {
+		t.Errorf("unexpected last day: %s", first.LastDay())
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	day := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	for locale, expected := range map[string]string{
+		"hu-HU":   "2020. 10. 01.",
+		"en-US":   "Oct 1, 2020",
+		"de-DE":   "01.10.2020",
+		"unknown": "2020. 10. 01.",
+	} {
+		if formatted := FormatDate(day, locale); formatted != expected {
+			t.Errorf("%s: expected %s, got %s", locale, expected, formatted)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package dynamo
 
 import (
+	"encoding/json"
 	"log"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -11,7 +14,10 @@ import (
 	expression "github.com/aws/aws-sdk-go/service/dynamodb/expression"
 	"github.com/pkg/errors"
 
+	"github.com/sylank/lavender-commons-go/dates"
+	"github.com/sylank/lavender-commons-go/money"
 	props "github.com/sylank/lavender-commons-go/properties"
+	"github.com/sylank/lavender-commons-go/retry"
 )
 
 // UserModel ...
@@ -33,28 +39,220 @@ type DeletionInsertModel struct {
 
 // ReservationModel ...
 type ReservationModel struct {
-	ReservationID    string `json:"ReservationId"`
-	FromDate         string `json:"FromDate"`
-	ToDate           string `json:"ToDate"`
-	UserID           string `json:"UserId"`
-	Deleted          bool   `json:"Deleted"`
-	CostValue        int    `json:"CostValue"`
-	DepositCostValue int    `json:"DepositCostValue"`
-	ApartmentCode    string `json:"ApartmentCode"`
+	ReservationID    string      `json:"ReservationId"`
+	FromDate         string      `json:"FromDate"`
+	ToDate           string      `json:"ToDate"`
+	UserID           string      `json:"UserId"`
+	Deleted          bool        `json:"Deleted"`
+	CostValue        money.Money `json:"CostValue"`
+	DepositCostValue money.Money `json:"DepositCostValue"`
+	ApartmentCode    string      `json:"ApartmentCode"`
+}
+
+// reservationJSON is the JSON form of the reservation, the amounts stay numbers of whole currency units as before
+// the money type, the Currency field is added next to them
+type reservationJSON struct {
+	*reservationFields
+	CostValue        json.Number `json:"CostValue"`
+	DepositCostValue json.Number `json:"DepositCostValue"`
+	Currency         string      `json:"Currency,omitempty"`
+}
+
+type reservationFields ReservationModel
+
+// MarshalJSON writes CostValue and DepositCostValue as numbers, e.g. 25000 or 125.50, with a separate Currency
+func (reservation ReservationModel) MarshalJSON() ([]byte, error) {
+	costValue, depositCostValue, err := reservation.amounts()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(reservationJSON{
+		reservationFields: (*reservationFields)(&reservation),
+		CostValue:         json.Number(costValue.MajorUnitsString()),
+		DepositCostValue:  json.Number(depositCostValue.MajorUnitsString()),
+		Currency:          costValue.Currency,
+	})
+}
+
+// UnmarshalJSON reads the numeric amounts, a missing Currency means money.DefaultCurrency
+func (reservation *ReservationModel) UnmarshalJSON(data []byte) error {
+	value := reservationJSON{reservationFields: (*reservationFields)(reservation)}
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	reservation.CostValue, err = parseJSONAmount(value.CostValue, value.Currency)
+	if err != nil {
+		return err
+	}
+
+	reservation.DepositCostValue, err = parseJSONAmount(value.DepositCostValue, value.Currency)
+	return err
+}
+
+func parseJSONAmount(amount json.Number, currency string) (money.Money, error) {
+	if amount == "" {
+		amount = "0"
+	}
+
+	return money.ParseMajorUnits(amount.String(), currency)
+}
+
+// BalanceDue returns the cost of the reservation minus the deposit
+func (reservation *ReservationModel) BalanceDue() (money.Money, error) {
+	return money.BalanceDue(reservation.CostValue, reservation.DepositCostValue)
+}
+
+// DateRange returns the nights of the reservation in the location of the apartment, ToDate is the exclusive
+// check-out day
+func (reservation *ReservationModel) DateRange(location *time.Location) (dates.Range, error) {
+	period, err := dates.ParseRange(reservation.FromDate, reservation.ToDate, location)
+	if err != nil {
+		return dates.Range{}, err
+	}
+
+	return period.Days(), nil
 }
 
 // ReservationDynamoModel ...
 type ReservationDynamoModel struct {
-	ReservationID    string
+	ReservationID    string `dynamodbav:"ReservationId"`
 	FromDate         string
 	ToDate           string
-	UserID           string
+	UserID           string `dynamodbav:"UserId"`
 	Deleted          string
 	CostValue        string
 	DepositCostValue string
+	Currency         string
 	ApartmentCode    string
 }
 
+// ToDynamoModel converts the reservation to its stored form, amounts are kept in minor units. An amount without
+// currency, e.g. a zero deposit, takes the currency of the other amount, money.DefaultCurrency when neither has one.
+func (reservation *ReservationModel) ToDynamoModel() (*ReservationDynamoModel, error) {
+	costValue, depositCostValue, err := reservation.amounts()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReservationDynamoModel{
+		ReservationID:    reservation.ReservationID,
+		FromDate:         reservation.FromDate,
+		ToDate:           reservation.ToDate,
+		UserID:           reservation.UserID,
+		Deleted:          strconv.FormatBool(reservation.Deleted),
+		CostValue:        costValue.MinorUnitsString(),
+		DepositCostValue: depositCostValue.MinorUnitsString(),
+		Currency:         costValue.Currency,
+		ApartmentCode:    reservation.ApartmentCode,
+	}, nil
+}
+
+// amounts returns the cost and the deposit in the same currency, an amount without currency takes the currency
+// of the other amount
+func (reservation *ReservationModel) amounts() (money.Money, money.Money, error) {
+	currency := reservation.CostValue.Currency
+	if currency == "" {
+		currency = reservation.DepositCostValue.Currency
+	}
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
+	costValue := withCurrency(reservation.CostValue, currency)
+	depositCostValue := withCurrency(reservation.DepositCostValue, currency)
+	if costValue.Currency != depositCostValue.Currency {
+		return money.Money{}, money.Money{}, errors.Wrapf(money.ErrCurrencyMismatch, "reservation: %s", reservation.ReservationID)
+	}
+
+	return costValue, depositCostValue, nil
+}
+
+// ToReservationModel converts a stored reservation. Records written before the Currency attribute hold whole
+// forints, they are read as money.DefaultCurrency major units. A missing Deleted attribute means not deleted.
+func (item *ReservationDynamoModel) ToReservationModel() (*ReservationModel, error) {
+	deleted := false
+	if item.Deleted != "" {
+		var err error
+		deleted, err = strconv.ParseBool(item.Deleted)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid Deleted value: %s", item.Deleted)
+		}
+	}
+
+	parseAmount := money.ParseMinorUnits
+	if item.Currency == "" {
+		parseAmount = parseLegacyAmount
+	}
+
+	costValue, err := parseAmount(item.CostValue, item.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	depositCostValue, err := parseAmount(item.DepositCostValue, item.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReservationModel{
+		ReservationID:    item.ReservationID,
+		FromDate:         item.FromDate,
+		ToDate:           item.ToDate,
+		UserID:           item.UserID,
+		Deleted:          deleted,
+		CostValue:        costValue,
+		DepositCostValue: depositCostValue,
+		ApartmentCode:    item.ApartmentCode,
+	}, nil
+}
+
+func withCurrency(amount money.Money, currency string) money.Money {
+	if amount.Currency == "" {
+		amount.Currency = currency
+	}
+
+	return amount
+}
+
+// parseLegacyAmount reads the whole forint amounts of the records without currency, a missing amount is zero
+func parseLegacyAmount(amount string, currency string) (money.Money, error) {
+	amount = strings.TrimSpace(amount)
+	if amount == "" {
+		return money.New(0, money.DefaultCurrency), nil
+	}
+
+	value, err := strconv.ParseInt(amount, 10, 64)
+	if err != nil {
+		return money.Money{}, errors.Wrapf(err, "invalid amount: %s", amount)
+	}
+
+	return money.FromMajor(value, money.DefaultCurrency), nil
+}
+
+// MarshalReservation ...
+func MarshalReservation(reservationModel *ReservationModel) (map[string]*dynamodb.AttributeValue, error) {
+	item, err := reservationModel.ToDynamoModel()
+	if err != nil {
+		return nil, err
+	}
+
+	return dynamodbattribute.MarshalMap(item)
+}
+
+// UnmarshalReservation ...
+func UnmarshalReservation(av map[string]*dynamodb.AttributeValue) (*ReservationModel, error) {
+	item := ReservationDynamoModel{}
+	err := dynamodbattribute.UnmarshalMap(av, &item)
+	if err != nil {
+		return nil, err
+	}
+
+	return item.ToReservationModel()
+}
+
 var client *dynamodb.DynamoDB
 var properties *props.DynamoProperties
 
@@ -65,7 +263,7 @@ func CreateConnection(dynamoProperties *props.DynamoProperties) *dynamodb.Dynamo
 		SharedConfigState: session.SharedConfigEnable,
 	}))
 
-	client = dynamodb.New(sess)
+	client = dynamodb.New(sess, retry.AWSConfig())
 
 	return client
 }
@@ -130,7 +328,7 @@ func FetchTable(table string, proj expression.ProjectionBuilder) (*dynamodb.Scan
 	}
 
 	// Make the DynamoDB Query API call
-	result, err := client.Scan(params)
+	result, err := scan(params)
 	if err != nil {
 		log.Println("Custom query API call failed:")
 		log.Println((err.Error()))
@@ -165,7 +363,7 @@ func query(filterBuilder expression.ConditionBuilder, table string, proj express
 	}
 
 	// Make the DynamoDB Query API call
-	result, err := client.Scan(params)
+	result, err := scan(params)
 	if err != nil {
 		log.Println("Custom query API call failed:")
 		log.Println((err.Error()))
@@ -256,7 +454,7 @@ func ClearUserData(userID string) error {
 			UpdateExpression: aws.String("set Email = :r, FullName = :r, Phone = :r"),
 		}
 
-		_, updateError := client.UpdateItem(input)
+		_, updateError := updateItem(input)
 		if updateError != nil {
 			log.Println(updateError.Error())
 			return errors.New("Updating error")
@@ -288,7 +486,7 @@ func InsertDeletionTypeTable(deletionModel *DeletionInsertModel, tableName strin
 		TableName: aws.String(tableName),
 	}
 
-	_, err = GetDynamoClient().PutItem(input)
+	_, err = putItem(input)
 	if err != nil {
 		log.Println("Got error calling PutItem:")
 		log.Println(err.Error())
@@ -311,6 +509,7 @@ func QueryReservationTypeTable(reservationID string, table string) ([]Reservatio
 		expression.Name("Deleted"),
 		expression.Name("DepositCostValue"),
 		expression.Name("CostValue"),
+		expression.Name("Currency"),
 		expression.Name("ApartmentCode"))
 	result, err := CustomQuery("ReservationId", reservationID, table, proj)
 	if err != nil {
@@ -323,32 +522,13 @@ func QueryReservationTypeTable(reservationID string, table string) ([]Reservatio
 	for _, i := range result.Items {
 		log.Println("Marshalling:")
 		log.Println(i)
-		item := ReservationDynamoModel{}
-		err = dynamodbattribute.UnmarshalMap(i, &item)
-
-		if err != nil {
-			log.Println("Got error unmarshalling:", err)
-			return nil, err
-		}
-
-		deleted, err := strconv.ParseBool(item.Deleted)
-		costValue, err := strconv.Atoi(item.CostValue)
-		depositCostValue, err := strconv.Atoi(item.DepositCostValue)
+		item, err := UnmarshalReservation(i)
 		if err != nil {
 			log.Println("Failed to convert values", err)
 			return nil, err
 		}
 
-		retData = append(retData, ReservationModel{
-			ReservationID:    item.ReservationID,
-			FromDate:         item.FromDate,
-			ToDate:           item.ToDate,
-			UserID:           item.UserID,
-			Deleted:          deleted,
-			CostValue:        costValue,
-			DepositCostValue: depositCostValue,
-			ApartmentCode:    item.ApartmentCode,
-		})
+		retData = append(retData, *item)
 	}
 
 	log.Println("QueryReservationTypeTable returns with")
@@ -357,11 +537,12 @@ func QueryReservationTypeTable(reservationID string, table string) ([]Reservatio
 }
 
 // InsertReservationTypeTable ...
-func InsertReservationTypeTable(reservationModel *ReservationModel, table string) {
-	av, err := dynamodbattribute.MarshalMap(reservationModel)
+func InsertReservationTypeTable(reservationModel *ReservationModel, table string) error {
+	av, err := MarshalReservation(reservationModel)
 	if err != nil {
-		log.Println("Got error marshalling new reservationModel item:")
-		log.Println(err.Error())
+		log.Println("Got error marshalling new reservationModel item:", err)
+
+		return err
 	}
 
 	input := &dynamodb.PutItemInput{
@@ -369,13 +550,16 @@ func InsertReservationTypeTable(reservationModel *ReservationModel, table string
 		TableName: aws.String(table),
 	}
 
-	_, err = client.PutItem(input)
+	_, err = putItem(input)
 	if err != nil {
-		log.Println("Got error calling PutItem:")
-		log.Println(err.Error())
+		log.Println("Got error calling PutItem:", err)
+
+		return err
 	}
 
 	log.Println("Item inserted with reservationId: " + reservationModel.ReservationID)
+
+	return nil
 }
 
 // DeleteReservationType ...
@@ -389,7 +573,7 @@ func DeleteReservationType(reservationID string, table string) error {
 		TableName: aws.String(table),
 	}
 
-	_, err := client.DeleteItem(input)
+	_, err := deleteItem(input)
 	if err != nil {
 		log.Println("Got error calling DeleteItem", err)
 
@@ -419,7 +603,7 @@ func UpdateDeletedReservationStatus(reservationID string, userID string, table s
 		UpdateExpression: aws.String("set Deleted = :r"),
 	}
 
-	_, updateError := client.UpdateItem(input)
+	_, updateError := updateItem(input)
 	if updateError != nil {
 		log.Println(updateError.Error())
 
@@ -430,3 +614,76 @@ func UpdateDeletedReservationStatus(reservationID string, userID string, table s
 
 	return nil
 }
+
+// QueryReservationsByApartment returns every reservation of the apartment, deleted ones included
+func QueryReservationsByApartment(apartmentCode string, table string) ([]ReservationModel, error) {
+	filt := expression.Name("ApartmentCode").Equal(expression.Value(apartmentCode))
+	expr, err := expression.NewBuilder().WithFilter(filt).Build()
+	if err != nil {
+		log.Println("Got error building expression:", err)
+		return nil, err
+	}
+
+	params := &dynamodb.ScanInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+		TableName:                 aws.String(table),
+	}
+
+	retData := []ReservationModel{}
+	for {
+		result, err := scan(params)
+		if err != nil {
+			log.Println("QueryReservationsByApartment scan API call failed:", err)
+			return nil, err
+		}
+
+		for _, i := range result.Items {
+			item, err := UnmarshalReservation(i)
+			if err != nil {
+				log.Println("Failed to convert values", err)
+				return nil, err
+			}
+
+			retData = append(retData, *item)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return retData, nil
+		}
+		params.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// UpdateReservationDates ...
+func UpdateReservationDates(reservationID string, fromDate string, toDate string, table string) error {
+	input := &dynamodb.UpdateItemInput{
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":f": {
+				S: aws.String(fromDate),
+			},
+			":t": {
+				S: aws.String(toDate),
+			},
+		},
+		TableName: aws.String(table),
+		Key: map[string]*dynamodb.AttributeValue{
+			"ReservationId": {
+				S: aws.String(reservationID),
+			},
+		},
+		UpdateExpression: aws.String("set FromDate = :f, ToDate = :t"),
+	}
+
+	_, err := updateItem(input)
+	if err != nil {
+		log.Println("Got error calling UpdateItem", err)
+
+		return err
+	}
+
+	log.Println("Dates updated with reservationId: " + reservationID)
+
+	return nil
+}
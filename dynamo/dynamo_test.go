@@ -0,0 +1,150 @@
+package dynamo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/sylank/lavender-commons-go/money"
+)
+
+func TestUnmarshalReservation(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		item            map[string]*dynamodb.AttributeValue
+		expectedCost    money.Money
+		expectedDeposit money.Money
+	}{
+		{
+			desc: "legacy row with whole forints",
+			item: map[string]*dynamodb.AttributeValue{
+				"ReservationId":    {S: aws.String("r1")},
+				"Deleted":          {S: aws.String("false")},
+				"CostValue":        {N: aws.String("25000")},
+				"DepositCostValue": {N: aws.String("5000")},
+			},
+			expectedCost:    money.FromMajor(25000, "HUF"),
+			expectedDeposit: money.FromMajor(5000, "HUF"),
+		},
+		{
+			desc: "legacy row without Deleted",
+			item: map[string]*dynamodb.AttributeValue{
+				"ReservationId": {S: aws.String("r3")},
+				"CostValue":     {S: aws.String("18000")},
+			},
+			expectedCost:    money.FromMajor(18000, "HUF"),
+			expectedDeposit: money.New(0, "HUF"),
+		},
+		{
+			desc: "row with currency in minor units",
+			item: map[string]*dynamodb.AttributeValue{
+				"ReservationId":    {S: aws.String("r2")},
+				"Deleted":          {S: aws.String("false")},
+				"CostValue":        {S: aws.String("12550")},
+				"DepositCostValue": {S: aws.String("2500")},
+				"Currency":         {S: aws.String("EUR")},
+			},
+			expectedCost:    money.New(12550, "EUR"),
+			expectedDeposit: money.New(2500, "EUR"),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			reservation, err := UnmarshalReservation(tC.item)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reservation.Deleted {
+				t.Fatal("reservation should not be deleted")
+			}
+			if reservation.CostValue != tC.expectedCost || reservation.DepositCostValue != tC.expectedDeposit {
+				t.Fatalf("unexpected amounts: %s, %s", reservation.CostValue, reservation.DepositCostValue)
+			}
+
+			av, err := MarshalReservation(reservation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			roundTripped, err := UnmarshalReservation(av)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if roundTripped.CostValue != tC.expectedCost || roundTripped.DepositCostValue != tC.expectedDeposit {
+				t.Errorf("amounts changed on round trip: %s, %s", roundTripped.CostValue, roundTripped.DepositCostValue)
+			}
+		})
+	}
+}
+
+func TestMarshalReservationWithoutDeposit(t *testing.T) {
+	av, err := MarshalReservation(&ReservationModel{
+		ReservationID: "r1",
+		CostValue:     money.FromMajor(25000, "HUF"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reservation, err := UnmarshalReservation(av)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reservation.DepositCostValue != money.New(0, "HUF") || reservation.CostValue != money.FromMajor(25000, "HUF") {
+		t.Errorf("unexpected amounts: %s, %s", reservation.CostValue, reservation.DepositCostValue)
+	}
+}
+
+func TestReservationJSON(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		reservation ReservationModel
+		expected    string
+	}{
+		{
+			desc:        "whole forints",
+			reservation: ReservationModel{ReservationID: "r1", CostValue: money.FromMajor(25000, "HUF"), DepositCostValue: money.FromMajor(5000, "HUF")},
+			expected:    `{"ReservationId":"r1","FromDate":"","ToDate":"","UserId":"","Deleted":false,"ApartmentCode":"","CostValue":25000,"DepositCostValue":5000,"Currency":"HUF"}`,
+		},
+		{
+			desc:        "euro cents without deposit",
+			reservation: ReservationModel{ReservationID: "r2", CostValue: money.New(12550, "EUR")},
+			expected:    `{"ReservationId":"r2","FromDate":"","ToDate":"","UserId":"","Deleted":false,"ApartmentCode":"","CostValue":125.50,"DepositCostValue":0,"Currency":"EUR"}`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			data, err := json.Marshal(tC.reservation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tC.expected {
+				t.Fatalf("unexpected JSON: %s", data)
+			}
+
+			reservation := ReservationModel{}
+			if err := json.Unmarshal(data, &reservation); err != nil {
+				t.Fatal(err)
+			}
+			if reservation.CostValue != tC.reservation.CostValue || reservation.DepositCostValue.Currency != tC.reservation.CostValue.Currency {
+				t.Errorf("amounts changed on round trip: %s, %s", reservation.CostValue, reservation.DepositCostValue)
+			}
+		})
+	}
+}
+
+func TestUnmarshalLegacyReservationJSON(t *testing.T) {
+	reservation := ReservationModel{}
+	err := json.Unmarshal([]byte(`{"ReservationId":"r1","Deleted":true,"CostValue":25000,"DepositCostValue":5000}`), &reservation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reservation.Deleted || reservation.ReservationID != "r1" {
+		t.Errorf("unexpected reservation: %+v", reservation)
+	}
+	if reservation.CostValue != money.FromMajor(25000, "HUF") || reservation.DepositCostValue != money.FromMajor(5000, "HUF") {
+		t.Errorf("unexpected amounts: %s, %s", reservation.CostValue, reservation.DepositCostValue)
+	}
+}
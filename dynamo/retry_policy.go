@@ -0,0 +1,69 @@
+package dynamo
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/sylank/lavender-commons-go/retry"
+)
+
+var retryPolicy = retry.AWSPolicy()
+
+// SetRetryPolicy sets the policy used for throttled and failed DynamoDB calls
+func SetRetryPolicy(policy retry.Policy) {
+	retryPolicy = policy
+}
+
+func scan(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	var output *dynamodb.ScanOutput
+	err := retryPolicy.Do(func() error {
+		var err error
+		output, err = client.Scan(input)
+		return err
+	})
+
+	return output, err
+}
+
+func putItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	var output *dynamodb.PutItemOutput
+	err := retryPolicy.Do(func() error {
+		var err error
+		output, err = client.PutItem(input)
+		return err
+	})
+
+	return output, err
+}
+
+func updateItem(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	var output *dynamodb.UpdateItemOutput
+	err := retryPolicy.Do(func() error {
+		var err error
+		output, err = client.UpdateItem(input)
+		return err
+	})
+
+	return output, err
+}
+
+func deleteItem(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	var output *dynamodb.DeleteItemOutput
+	err := retryPolicy.Do(func() error {
+		var err error
+		output, err = client.DeleteItem(input)
+		return err
+	})
+
+	return output, err
+}
+
+func transactWriteItems(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	var output *dynamodb.TransactWriteItemsOutput
+	err := retryPolicy.Do(func() error {
+		var err error
+		output, err = client.TransactWriteItems(input)
+		return err
+	})
+
+	return output, err
+}
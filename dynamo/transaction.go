@@ -0,0 +1,98 @@
+package dynamo
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// ReservationPutItem returns the put of the reservation for a TransactWriteItems call
+func ReservationPutItem(reservationModel *ReservationModel, table string) (*dynamodb.TransactWriteItem, error) {
+	av, err := MarshalReservation(reservationModel)
+	if err != nil {
+		log.Println("Got error marshalling new reservationModel item:", err)
+		return nil, err
+	}
+
+	return &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			Item:      av,
+			TableName: aws.String(table),
+		},
+	}, nil
+}
+
+// DeletedReservationStatusUpdateItem returns the soft-delete of the reservation for a TransactWriteItems call
+func DeletedReservationStatusUpdateItem(reservationID string, table string) *dynamodb.TransactWriteItem {
+	return &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":r": {
+					S: aws.String(strconv.FormatBool(true)),
+				},
+			},
+			TableName: aws.String(table),
+			Key: map[string]*dynamodb.AttributeValue{
+				"ReservationId": {
+					S: aws.String(reservationID),
+				},
+			},
+			UpdateExpression: aws.String("set Deleted = :r"),
+		},
+	}
+}
+
+// ReservationDatesUpdateItem returns the date change of the reservation for a TransactWriteItems call
+func ReservationDatesUpdateItem(reservationID string, fromDate string, toDate string, table string) *dynamodb.TransactWriteItem {
+	return &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":f": {
+					S: aws.String(fromDate),
+				},
+				":t": {
+					S: aws.String(toDate),
+				},
+			},
+			TableName: aws.String(table),
+			Key: map[string]*dynamodb.AttributeValue{
+				"ReservationId": {
+					S: aws.String(reservationID),
+				},
+			},
+			UpdateExpression: aws.String("set FromDate = :f, ToDate = :t"),
+		},
+	}
+}
+
+// DeletionPutItem returns the insert of the deletion data for a TransactWriteItems call
+func DeletionPutItem(deletionModel *DeletionInsertModel, table string) (*dynamodb.TransactWriteItem, error) {
+	av, err := dynamodbattribute.MarshalMap(deletionModel)
+	if err != nil {
+		log.Println("Got error marshalling deletion item:", err)
+		return nil, err
+	}
+
+	return &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			Item:      av,
+			TableName: aws.String(table),
+		},
+	}, nil
+}
+
+// TransactWriteItems writes the items atomically with the client created by CreateConnection
+func TransactWriteItems(items []*dynamodb.TransactWriteItem) error {
+	_, err := transactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	if err != nil {
+		log.Println("Got error calling TransactWriteItems", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package formatter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSender writes the emails as .eml files into a directory, used for local development
+type FileSender struct {
+	dir         string
+	fromAddress string
+}
+
+// NewFileSender ...
+func NewFileSender(dir string, fromAddress string) *FileSender {
+	return &FileSender{dir: dir, fromAddress: fromAddress}
+}
+
+// Send ...
+func (sender *FileSender) Send(message *Message) error {
+	err := message.validate()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(sender.dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	fileName := filepath.Join(sender.dir, fmt.Sprintf("%s-%s.eml", now.Format("20060102-150405"), randomToken()))
+	err = ioutil.WriteFile(fileName, buildMIME(sender.fromAddress, message, now), 0644)
+	if err != nil {
+		log.Println("Unable to write email file: "+fileName, err)
+		return err
+	}
+
+	log.Println("Email written to: " + fileName)
+	return nil
+}
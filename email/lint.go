@@ -0,0 +1,154 @@
+package formatter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placeholders lists the placeholder names replaced by GenerateEmailText
+var Placeholders = []string{
+	"email",
+	"url",
+	"name",
+	"reservationId",
+	"fromDate",
+	"toDate",
+	"message",
+	"costValue",
+	"depositCost",
+	"balanceDue",
+}
+
+var placeholderPattern = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9]*)>`)
+var tagPattern = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)((?:\s[^>]*)?)>`)
+var commentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+var htmlElements = map[string]bool{
+	"a": true, "abbr": true, "address": true, "article": true, "aside": true, "b": true, "bdi": true,
+	"bdo": true, "blockquote": true, "body": true, "button": true, "caption": true, "center": true,
+	"cite": true, "code": true, "colgroup": true, "dd": true, "del": true, "details": true, "div": true,
+	"dl": true, "dt": true, "em": true, "fieldset": true, "figcaption": true, "figure": true, "font": true,
+	"footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"head": true, "header": true, "html": true, "i": true, "ins": true, "label": true, "legend": true,
+	"li": true, "main": true, "mark": true, "nav": true, "ol": true, "option": true, "p": true, "pre": true,
+	"q": true, "s": true, "section": true, "select": true, "small": true, "span": true, "strike": true,
+	"strong": true, "style": true, "sub": true, "summary": true, "sup": true, "table": true, "tbody": true,
+	"td": true, "textarea": true, "tfoot": true, "th": true, "thead": true, "title": true, "tr": true,
+	"u": true, "ul": true,
+}
+
+// LintIssue ...
+type LintIssue struct {
+	Line    int
+	Message string
+}
+
+func (issue LintIssue) String() string {
+	if issue.Line == 0 {
+		return issue.Message
+	}
+
+	return fmt.Sprintf("line %d: %s", issue.Line, issue.Message)
+}
+
+type openTag struct {
+	name string
+	line int
+}
+
+// LintTemplate reports unknown placeholders, missing required placeholders and unbalanced HTML tags
+func LintTemplate(text string, requiredPlaceholders []string) []LintIssue {
+	var issues []LintIssue
+
+	knownPlaceholders := map[string]bool{}
+	for _, placeholder := range Placeholders {
+		knownPlaceholders[placeholder] = true
+	}
+
+	// Comments are blanked out so tags inside them are ignored, newlines are kept for line numbers
+	text = commentPattern.ReplaceAllStringFunc(text, func(comment string) string {
+		return strings.Repeat("\n", strings.Count(comment, "\n"))
+	})
+
+	// Placeholders may also appear inside attribute values, so they are collected and blanked out first
+	usedPlaceholders := map[string]bool{}
+	blanked := []byte(text)
+	for _, match := range placeholderPattern.FindAllStringSubmatchIndex(text, -1) {
+		name := text[match[2]:match[3]]
+		lowerName := strings.ToLower(name)
+		if !knownPlaceholders[name] && (htmlElements[lowerName] || voidElements[lowerName]) {
+			continue
+		}
+
+		if knownPlaceholders[name] {
+			usedPlaceholders[name] = true
+		} else {
+			line := strings.Count(text[:match[0]], "\n") + 1
+			issues = append(issues, LintIssue{Line: line, Message: fmt.Sprintf("unknown placeholder <%s>", name)})
+		}
+
+		for i := match[0]; i < match[1]; i++ {
+			blanked[i] = ' '
+		}
+	}
+	text = string(blanked)
+
+	var stack []openTag
+	for _, match := range tagPattern.FindAllStringSubmatchIndex(text, -1) {
+		line := strings.Count(text[:match[0]], "\n") + 1
+		closing := match[3] > match[2]
+		name := text[match[4]:match[5]]
+		attributes := text[match[6]:match[7]]
+		lowerName := strings.ToLower(name)
+
+		if !htmlElements[lowerName] && !voidElements[lowerName] {
+			issues = append(issues, LintIssue{Line: line, Message: fmt.Sprintf("unknown HTML tag <%s%s>", text[match[2]:match[3]], name)})
+			continue
+		}
+
+		if voidElements[lowerName] || strings.HasSuffix(strings.TrimSpace(attributes), "/") {
+			continue
+		}
+
+		if !closing {
+			stack = append(stack, openTag{name: lowerName, line: line})
+			continue
+		}
+
+		if len(stack) == 0 || stack[len(stack)-1].name != lowerName {
+			issues = append(issues, LintIssue{Line: line, Message: fmt.Sprintf("unexpected closing tag </%s>", name)})
+			// Recover when the tag was opened earlier and the ones above it were left unclosed
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].name == lowerName {
+					for _, unclosed := range stack[i+1:] {
+						issues = append(issues, LintIssue{Line: unclosed.line, Message: fmt.Sprintf("unclosed tag <%s>", unclosed.name)})
+					}
+					stack = stack[:i]
+					break
+				}
+			}
+			continue
+		}
+
+		stack = stack[:len(stack)-1]
+	}
+
+	for _, unclosed := range stack {
+		issues = append(issues, LintIssue{Line: unclosed.line, Message: fmt.Sprintf("unclosed tag <%s>", unclosed.name)})
+	}
+
+	for _, placeholder := range requiredPlaceholders {
+		placeholder = strings.Trim(placeholder, "<> ")
+		if placeholder != "" && !usedPlaceholders[placeholder] {
+			issues = append(issues, LintIssue{Message: fmt.Sprintf("missing required placeholder <%s>", placeholder)})
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,55 @@
+package formatter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintTemplate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		text     string
+		required []string
+		expected []string
+	}{
+		{
+			desc:     "Valid template",
+			text:     "<html>\n<body>\n<p>Dear <name>,</p><br>\n<a href=\"<url>\">Cancel</a>\n<!-- <unused> -->\n</body>\n</html>",
+			required: []string{"name", "url"},
+			expected: nil,
+		},
+		{
+			desc:     "Unknown placeholder",
+			text:     "<p>Dear <fullName>,</p>",
+			expected: []string{"line 1: unknown placeholder <fullName>"},
+		},
+		{
+			desc:     "Missing required placeholder",
+			text:     "<p>Dear <name>,</p>",
+			required: []string{"<reservationId>"},
+			expected: []string{"missing required placeholder <reservationId>"},
+		},
+		{
+			desc:     "Unclosed tag",
+			text:     "<div>\n<p>Dear <name>,\n</div>",
+			expected: []string{"line 3: unexpected closing tag </div>", "line 2: unclosed tag <p>"},
+		},
+		{
+			desc:     "Unexpected closing tag",
+			text:     "<p>Dear <name>,</p>\n</td>",
+			expected: []string{"line 2: unexpected closing tag </td>"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var messages []string
+			for _, issue := range LintTemplate(tC.text, tC.required) {
+				messages = append(messages, issue.String())
+			}
+
+			if !reflect.DeepEqual(messages, tC.expected) {
+				t.Errorf("expected %v, got %v", tC.expected, messages)
+			}
+		})
+	}
+}
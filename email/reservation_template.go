@@ -1,9 +1,10 @@
 package formatter
 
 import (
-	"strconv"
 	"strings"
 
+	"github.com/sylank/lavender-commons-go/dates"
+	"github.com/sylank/lavender-commons-go/money"
 	"github.com/sylank/lavender-commons-go/utils"
 )
 
@@ -16,9 +17,11 @@ type EmailTemplate struct {
 	reservationID    string
 	fromDate         string
 	toDate           string
+	period           *dates.Range
 	message          string
-	costValue        int
-	depositCostValue int
+	locale           string
+	costValue        money.Money
+	depositCostValue money.Money
 }
 
 // InitEmail ...
@@ -46,14 +49,22 @@ func (template *EmailTemplate) SetReservationID(reservationID string) {
 	template.reservationID = reservationID
 }
 
-// SetFromDate ...
+// SetFromDate sets the check-in date as it is shown, SetDateRange formats it for the locale instead
 func (template *EmailTemplate) SetFromDate(fromDate string) {
 	template.fromDate = fromDate
+	template.period = nil
 }
 
-// SetToDate ...
+// SetToDate sets the check-out date as it is shown, SetDateRange formats it for the locale instead
 func (template *EmailTemplate) SetToDate(toDate string) {
 	template.toDate = toDate
+	template.period = nil
+}
+
+// SetDateRange sets the check-in and check-out dates, they are formatted for the locale
+func (template *EmailTemplate) SetDateRange(period dates.Range) {
+	days := period.Days()
+	template.period = &days
 }
 
 // SetMessage ...
@@ -61,29 +72,61 @@ func (template *EmailTemplate) SetMessage(message string) {
 	template.message = message
 }
 
+// SetLocale sets the locale used for formatting amounts, defaults to money.DefaultLocale
+func (template *EmailTemplate) SetLocale(locale string) {
+	template.locale = locale
+}
+
 // SetCostValue ...
-func (template *EmailTemplate) SetCostValue(costValue int) {
+func (template *EmailTemplate) SetCostValue(costValue money.Money) {
 	template.costValue = costValue
 }
 
 // SetDepositCostValue ...
-func (template *EmailTemplate) SetDepositCostValue(depositCostValue int) {
+func (template *EmailTemplate) SetDepositCostValue(depositCostValue money.Money) {
 	template.depositCostValue = depositCostValue
 }
 
+func (template *EmailTemplate) getLocale() string {
+	if template.locale == "" {
+		return money.DefaultLocale
+	}
+
+	return template.locale
+}
+
+func (template *EmailTemplate) formatBalanceDue() string {
+	balanceDue, err := money.BalanceDue(template.costValue, template.depositCostValue)
+	if err != nil {
+		return ""
+	}
+
+	return balanceDue.Format(template.getLocale())
+}
+
+func (template *EmailTemplate) formatDates() (string, string) {
+	if template.period == nil {
+		return template.fromDate, template.toDate
+	}
+
+	return dates.FormatDate(template.period.Start, template.getLocale()), dates.FormatDate(template.period.End, template.getLocale())
+}
+
 // GenerateEmailText ...
 func (template *EmailTemplate) GenerateEmailText() string {
 	var tmpText = template.rawText
+	fromDate, toDate := template.formatDates()
 	r := strings.NewReplacer(
 		"<email>", template.email,
 		"<url>", template.deletionURL,
 		"<name>", template.name,
 		"<reservationId>", template.reservationID,
-		"<fromDate>", template.fromDate,
-		"<toDate>", template.toDate,
+		"<fromDate>", fromDate,
+		"<toDate>", toDate,
 		"<message>", template.message,
-		"<costValue>", strconv.Itoa(template.costValue),
-		"<depositCost>", strconv.Itoa(template.depositCostValue))
+		"<costValue>", template.costValue.Format(template.getLocale()),
+		"<depositCost>", template.depositCostValue.Format(template.getLocale()),
+		"<balanceDue>", template.formatBalanceDue())
 
 	return r.Replace(tmpText)
 }
@@ -0,0 +1,53 @@
+package formatter
+
+import (
+	"testing"
+)
+
+func TestGenerateEmailTextDates(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		locale   string
+		fromDate string
+		toDate   string
+		expected string
+	}{
+		{
+			desc:     "hungarian dates",
+			locale:   "hu-HU",
+			fromDate: "2026-07-10",
+			toDate:   "2026-07-14",
+			expected: "2026. 07. 10. - 2026. 07. 14.",
+		},
+		{
+			desc:     "english dates from timestamps",
+			locale:   "en-US",
+			fromDate: "2026-07-10T14:00:00+02:00",
+			toDate:   "2026-07-14T10:00:00+02:00",
+			expected: "Jul 10, 2026 - Jul 14, 2026",
+		},
+		{
+			desc:     "unparsable dates are kept",
+			locale:   "en-US",
+			fromDate: "next Friday",
+			toDate:   "Sunday",
+			expected: "next Friday - Sunday",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			data := SampleTemplateData()
+			data.Locale = tC.locale
+			data.FromDate = tC.fromDate
+			data.ToDate = tC.toDate
+
+			template := EmailTemplate{}
+			template.InitEmailFromText("<fromDate> - <toDate>")
+			template.SetTemplateData(data)
+
+			if text := template.GenerateEmailText(); text != tC.expected {
+				t.Errorf("expected %q, got %q", tC.expected, text)
+			}
+		})
+	}
+}
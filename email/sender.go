@@ -0,0 +1,115 @@
+package formatter
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoRecipient ...
+var ErrNoRecipient = errors.New("email has no recipient")
+
+// Message is an email ready to be delivered, at least one of the bodies must be set
+type Message struct {
+	To       []string
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+// Sender delivers emails
+type Sender interface {
+	Send(message *Message) error
+}
+
+// NewMessageFromTemplate creates an HTML message from the generated template text
+func NewMessageFromTemplate(to string, subject string, template *EmailTemplate) *Message {
+	return &Message{
+		To:       []string{to},
+		Subject:  subject,
+		HTMLBody: template.GenerateEmailText(),
+	}
+}
+
+func (message *Message) validate() error {
+	if len(message.To) == 0 {
+		return ErrNoRecipient
+	}
+	if message.HTMLBody == "" && message.TextBody == "" {
+		return errors.New("email has no body")
+	}
+
+	return nil
+}
+
+// buildMIME returns the RFC 5322 representation of the message, with a multipart/alternative
+// body when both the text and the HTML body are set
+func buildMIME(from string, message *Message, date time.Time) []byte {
+	var buffer bytes.Buffer
+
+	writeHeader(&buffer, "From", from)
+	writeHeader(&buffer, "To", strings.Join(message.To, ", "))
+	writeHeader(&buffer, "Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	writeHeader(&buffer, "Date", date.Format(time.RFC1123Z))
+	writeHeader(&buffer, "Message-ID", fmt.Sprintf("<%s@%s>", randomToken(), domainOf(from)))
+	writeHeader(&buffer, "MIME-Version", "1.0")
+
+	switch {
+	case message.TextBody != "" && message.HTMLBody != "":
+		boundary := "lavender-" + randomToken()
+		writeHeader(&buffer, "Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", boundary))
+		buffer.WriteString("\r\n")
+
+		fmt.Fprintf(&buffer, "--%s\r\n", boundary)
+		writePart(&buffer, "text/plain", message.TextBody)
+		fmt.Fprintf(&buffer, "--%s\r\n", boundary)
+		writePart(&buffer, "text/html", message.HTMLBody)
+		fmt.Fprintf(&buffer, "--%s--\r\n", boundary)
+	case message.HTMLBody != "":
+		writePart(&buffer, "text/html", message.HTMLBody)
+	default:
+		writePart(&buffer, "text/plain", message.TextBody)
+	}
+
+	return buffer.Bytes()
+}
+
+func writeHeader(buffer *bytes.Buffer, name string, value string) {
+	fmt.Fprintf(buffer, "%s: %s\r\n", name, value)
+}
+
+func writePart(buffer *bytes.Buffer, contentType string, body string) {
+	writeHeader(buffer, "Content-Type", contentType+"; charset=utf-8")
+	writeHeader(buffer, "Content-Transfer-Encoding", "quoted-printable")
+	buffer.WriteString("\r\n")
+
+	writer := quotedprintable.NewWriter(buffer)
+	writer.Write([]byte(body))
+	writer.Close()
+	buffer.WriteString("\r\n")
+}
+
+func domainOf(address string) string {
+	if index := strings.LastIndex(address, "@"); index >= 0 {
+		return strings.TrimSuffix(address[index+1:], ">")
+	}
+
+	return "localhost"
+}
+
+func randomToken() string {
+	token := make([]byte, 12)
+	_, err := rand.Read(token)
+	if err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(token)
+}
@@ -0,0 +1,185 @@
+package formatter
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+// fakeSMTPServer accepts a single session and records the authentication, envelope and data
+type fakeSMTPServer struct {
+	listener   net.Listener
+	extensions []string
+	auth       string
+	from       string
+	recipients []string
+	data       string
+	done       chan struct{}
+}
+
+func startFakeSMTPServer(t *testing.T, extensions ...string) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &fakeSMTPServer{listener: listener, extensions: extensions, done: make(chan struct{})}
+	go server.serve()
+
+	return server
+}
+
+func (server *fakeSMTPServer) port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (server *fakeSMTPServer) serve() {
+	defer close(server.done)
+
+	conn, err := server.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost fake SMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch command {
+		case "EHLO":
+			reply("250-localhost")
+			for _, extension := range server.extensions {
+				reply("250-" + extension)
+			}
+			reply("250 OK")
+		case "AUTH":
+			server.auth = line
+			reply("235 Authentication successful")
+		case "MAIL":
+			server.from = line
+			reply("250 OK")
+		case "RCPT":
+			server.recipients = append(server.recipients, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil || dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			server.data = data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func testMessage() *Message {
+	return &Message{
+		To:       []string{"guest@example.com"},
+		Subject:  "Foglalás visszaigazolása",
+		HTMLBody: "<p>Dear Guest,</p>",
+		TextBody: "Dear Guest,",
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	server := startFakeSMTPServer(t, "AUTH PLAIN")
+	defer server.listener.Close()
+
+	sender := NewSMTPSender("localhost", server.port(), &props.EmailSecrets{FromAddress: "booking@lavender.hu", Password: "secret"})
+	sender.RequireTLS = false
+
+	err := sender.Send(testMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-server.done
+
+	expectedAuth := "AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00booking@lavender.hu\x00secret"))
+	if server.auth != expectedAuth {
+		t.Errorf("unexpected authentication: %q", server.auth)
+	}
+
+	if server.from != "MAIL FROM:<booking@lavender.hu>" || len(server.recipients) != 1 {
+		t.Errorf("unexpected envelope: %s %v", server.from, server.recipients)
+	}
+
+	for _, expected := range []string{
+		"Subject: =?utf-8?q?Foglal=C3=A1s_visszaigazol=C3=A1sa?=",
+		"Content-Type: multipart/alternative",
+		"Content-Type: text/html; charset=utf-8",
+		"<p>Dear Guest,</p>",
+	} {
+		if !strings.Contains(server.data, expected) {
+			t.Errorf("message should contain %q:\n%s", expected, server.data)
+		}
+	}
+}
+
+func TestSMTPSenderRequiresTLS(t *testing.T) {
+	server := startFakeSMTPServer(t, "AUTH PLAIN")
+	defer server.listener.Close()
+
+	sender := NewSMTPSender("localhost", server.port(), &props.EmailSecrets{FromAddress: "booking@lavender.hu", Password: "secret"})
+
+	err := sender.Send(testMessage())
+	if errors.Cause(err) != ErrStartTLSNotSupported {
+		t.Errorf("expected STARTTLS error, got %v", err)
+	}
+}
+
+func TestFileSender(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lavender-mailbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sender := NewFileSender(filepath.Join(dir, "outbox"), "booking@lavender.hu")
+	for i := 0; i < 2; i++ {
+		if err := sender.Send(testMessage()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "outbox", "*.eml"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 eml files, got %d", len(files))
+	}
+
+	content, _ := ioutil.ReadFile(files[0])
+	if !strings.Contains(string(content), "To: guest@example.com") {
+		t.Errorf("unexpected eml content:\n%s", content)
+	}
+
+	if err := sender.Send(&Message{Subject: "no recipient", TextBody: "text"}); err != ErrNoRecipient {
+		t.Errorf("expected missing recipient error, got %v", err)
+	}
+}
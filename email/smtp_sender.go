@@ -0,0 +1,101 @@
+package formatter
+
+import (
+	"crypto/tls"
+	"log"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+// ErrStartTLSNotSupported is returned when TLS is required but the server does not offer STARTTLS
+var ErrStartTLSNotSupported = errors.New("SMTP server does not support STARTTLS")
+
+// SMTPSender delivers emails through an SMTP server, authenticating with the from address and password
+type SMTPSender struct {
+	// RequireTLS refuses to send when the server does not offer STARTTLS, enabled by default
+	RequireTLS bool
+	// TLSConfig overrides the TLS configuration used for STARTTLS
+	TLSConfig *tls.Config
+
+	host    string
+	port    int
+	secrets *props.EmailSecrets
+}
+
+// NewSMTPSender ...
+func NewSMTPSender(host string, port int, secrets *props.EmailSecrets) *SMTPSender {
+	return &SMTPSender{
+		RequireTLS: true,
+		host:       host,
+		port:       port,
+		secrets:    secrets,
+	}
+}
+
+// Send ...
+func (sender *SMTPSender) Send(message *Message) error {
+	err := message.validate()
+	if err != nil {
+		return err
+	}
+
+	address := net.JoinHostPort(sender.host, strconv.Itoa(sender.port))
+	client, err := smtp.Dial(address)
+	if err != nil {
+		log.Println("Unable to connect to SMTP server: "+address, err)
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		tlsConfig := sender.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: sender.host}
+		}
+
+		if err = client.StartTLS(tlsConfig); err != nil {
+			log.Println("STARTTLS failed", err)
+			return err
+		}
+	} else if sender.RequireTLS {
+		return errors.Wrap(ErrStartTLSNotSupported, address)
+	}
+
+	if ok, _ := client.Extension("AUTH"); ok && sender.secrets.Password != "" {
+		auth := smtp.PlainAuth("", sender.secrets.FromAddress, sender.secrets.Password, sender.host)
+		if err = client.Auth(auth); err != nil {
+			log.Println("SMTP authentication failed", err)
+			return err
+		}
+	}
+
+	if err = client.Mail(sender.secrets.FromAddress); err != nil {
+		return err
+	}
+	for _, recipient := range message.To {
+		if err = client.Rcpt(recipient); err != nil {
+			return errors.Wrapf(err, "recipient rejected: %s", recipient)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(buildMIME(sender.secrets.FromAddress, message, time.Now())); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+
+	log.Println("Email sent to: " + strings.Join(message.To, ", "))
+	return client.Quit()
+}
@@ -0,0 +1,73 @@
+package formatter
+
+import (
+	"encoding/json"
+
+	"github.com/sylank/lavender-commons-go/dates"
+	"github.com/sylank/lavender-commons-go/money"
+)
+
+// TemplateData holds every value that can be substituted into a reservation email template
+type TemplateData struct {
+	Email            string      `json:"email"`
+	Name             string      `json:"name"`
+	DeletionURL      string      `json:"url"`
+	ReservationID    string      `json:"reservationId"`
+	FromDate         string      `json:"fromDate"`
+	ToDate           string      `json:"toDate"`
+	Message          string      `json:"message"`
+	Locale           string      `json:"locale"`
+	CostValue        money.Money `json:"costValue"`
+	DepositCostValue money.Money `json:"depositCost"`
+}
+
+// SampleTemplateData returns placeholder values for previewing templates
+func SampleTemplateData() *TemplateData {
+	return &TemplateData{
+		Email:            "guest@example.com",
+		Name:             "Sample Guest",
+		DeletionURL:      "https://example.com/reservation/delete?id=sample-reservation-id",
+		ReservationID:    "sample-reservation-id",
+		FromDate:         "2026-07-10",
+		ToDate:           "2026-07-15",
+		Message:          "Sample message from the guest",
+		Locale:           money.DefaultLocale,
+		CostValue:        money.FromMajor(125000, money.DefaultCurrency),
+		DepositCostValue: money.FromMajor(37500, money.DefaultCurrency),
+	}
+}
+
+// ParseTemplateData decodes template data from JSON, missing values are taken from SampleTemplateData
+func ParseTemplateData(data []byte) (*TemplateData, error) {
+	templateData := SampleTemplateData()
+	err := json.Unmarshal(data, templateData)
+	if err != nil {
+		return nil, err
+	}
+
+	return templateData, nil
+}
+
+// InitEmailFromText ...
+func (template *EmailTemplate) InitEmailFromText(text string) {
+	template.rawText = text
+}
+
+// SetTemplateData sets every template value at once
+func (template *EmailTemplate) SetTemplateData(data *TemplateData) {
+	template.SetEmail(data.Email)
+	template.SetName(data.Name)
+	template.SetDeletionURL(data.DeletionURL)
+	template.SetReservationID(data.ReservationID)
+	template.SetFromDate(data.FromDate)
+	template.SetToDate(data.ToDate)
+	if location, err := dates.LoadLocation(""); err == nil {
+		if period, err := dates.ParseRange(data.FromDate, data.ToDate, location); err == nil {
+			template.SetDateRange(period)
+		}
+	}
+	template.SetMessage(data.Message)
+	template.SetLocale(data.Locale)
+	template.SetCostValue(data.CostValue)
+	template.SetDepositCostValue(data.DepositCostValue)
+}
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/pkg/errors"
+)
+
+// Handler reacts to a decoded event, the envelope carries the event ID for idempotency checks
+type Handler func(ctx context.Context, envelope *Envelope, event Event) error
+
+// Dispatcher routes decoded events to the handlers subscribed to their type
+type Dispatcher struct {
+	lock     sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewDispatcher ...
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: map[string][]Handler{}}
+}
+
+// Subscribe ...
+func (dispatcher *Dispatcher) Subscribe(eventType string, handler Handler) {
+	dispatcher.lock.Lock()
+	defer dispatcher.lock.Unlock()
+
+	dispatcher.handlers[eventType] = append(dispatcher.handlers[eventType], handler)
+}
+
+// Dispatch calls every handler of the event type, events without handlers are ignored.
+// All handlers are called even if one of them fails, the failures are returned together.
+func (dispatcher *Dispatcher) Dispatch(ctx context.Context, envelope *Envelope) error {
+	dispatcher.lock.RLock()
+	handlers := dispatcher.handlers[envelope.Type]
+	dispatcher.lock.RUnlock()
+
+	if len(handlers) == 0 {
+		log.Println("No handler subscribed to event: " + envelope.Type)
+		return nil
+	}
+
+	event, err := envelope.Decode()
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, handler := range handlers {
+		err := handler(ctx, envelope, event)
+		if err != nil {
+			log.Println("Event handler failed, event: "+envelope.Type+" id: "+envelope.ID, err)
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("%d handler(s) failed for event %s: %s", len(failures), envelope.ID, strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// HandleMessage decodes the message body and dispatches the event
+func (dispatcher *Dispatcher) HandleMessage(ctx context.Context, body string) error {
+	envelope, err := UnmarshalEnvelope(body)
+	if err != nil {
+		return err
+	}
+
+	return dispatcher.Dispatch(ctx, envelope)
+}
+
+// HandleSQSMessage can be used as the handler of a messaging.Consumer
+func (dispatcher *Dispatcher) HandleSQSMessage(ctx context.Context, message *sqs.Message) error {
+	return dispatcher.HandleMessage(ctx, aws.StringValue(message.Body))
+}
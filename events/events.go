@@ -0,0 +1,267 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sylank/lavender-commons-go/dynamo"
+	"github.com/sylank/lavender-commons-go/money"
+)
+
+// Event types
+const (
+	ReservationCreatedType   = "ReservationCreated"
+	ReservationCancelledType = "ReservationCancelled"
+	// ReservationDatesChangedType is emitted when the dates of a reservation were moved
+	ReservationDatesChangedType = "ReservationDatesChanged"
+	UserDataClearedType         = "UserDataCleared"
+	DepositReceivedType         = "DepositReceived"
+)
+
+// EnvelopeVersion is the current version of the event envelope
+const EnvelopeVersion = 1
+
+// ErrUnknownEventType ...
+var ErrUnknownEventType = errors.New("unknown event type")
+
+// ErrInvalidEnvelope ...
+var ErrInvalidEnvelope = errors.New("invalid event envelope")
+
+// Event is a domain event of the reservation lifecycle
+type Event interface {
+	EventType() string
+	// AggregateID is the ID of the reservation or user the event belongs to, events with the same ID are ordered
+	AggregateID() string
+}
+
+// ApartmentEvent is implemented by the events which belong to an apartment
+type ApartmentEvent interface {
+	Event
+	GetApartmentCode() string
+}
+
+// ReservationCreated ...
+type ReservationCreated struct {
+	ReservationID    string      `json:"reservationId"`
+	UserID           string      `json:"userId"`
+	ApartmentCode    string      `json:"apartmentCode"`
+	FromDate         string      `json:"fromDate"`
+	ToDate           string      `json:"toDate"`
+	CostValue        money.Money `json:"costValue"`
+	DepositCostValue money.Money `json:"depositCostValue"`
+}
+
+// ReservationCancelled ...
+type ReservationCancelled struct {
+	ReservationID string `json:"reservationId"`
+	UserID        string `json:"userId"`
+	ApartmentCode string `json:"apartmentCode"`
+	Reason        string `json:"reason"`
+}
+
+// ReservationDatesChanged ...
+type ReservationDatesChanged struct {
+	ReservationID    string `json:"reservationId"`
+	UserID           string `json:"userId"`
+	ApartmentCode    string `json:"apartmentCode"`
+	FromDate         string `json:"fromDate"`
+	ToDate           string `json:"toDate"`
+	PreviousFromDate string `json:"previousFromDate"`
+	PreviousToDate   string `json:"previousToDate"`
+}
+
+// UserDataCleared ...
+type UserDataCleared struct {
+	UserID string `json:"userId"`
+}
+
+// DepositReceived ...
+type DepositReceived struct {
+	ReservationID string      `json:"reservationId"`
+	ApartmentCode string      `json:"apartmentCode"`
+	Amount        money.Money `json:"amount"`
+}
+
+var eventFactories = map[string]func() Event{
+	ReservationCreatedType:      func() Event { return &ReservationCreated{} },
+	ReservationCancelledType:    func() Event { return &ReservationCancelled{} },
+	ReservationDatesChangedType: func() Event { return &ReservationDatesChanged{} },
+	UserDataClearedType:         func() Event { return &UserDataCleared{} },
+	DepositReceivedType:         func() Event { return &DepositReceived{} },
+}
+
+// NewReservationCreated ...
+func NewReservationCreated(reservation *dynamo.ReservationModel) *ReservationCreated {
+	return &ReservationCreated{
+		ReservationID:    reservation.ReservationID,
+		UserID:           reservation.UserID,
+		ApartmentCode:    reservation.ApartmentCode,
+		FromDate:         reservation.FromDate,
+		ToDate:           reservation.ToDate,
+		CostValue:        reservation.CostValue,
+		DepositCostValue: reservation.DepositCostValue,
+	}
+}
+
+// NewReservationCancelled ...
+func NewReservationCancelled(reservation *dynamo.ReservationModel, reason string) *ReservationCancelled {
+	return &ReservationCancelled{
+		ReservationID: reservation.ReservationID,
+		UserID:        reservation.UserID,
+		ApartmentCode: reservation.ApartmentCode,
+		Reason:        reason,
+	}
+}
+
+// NewReservationDatesChanged returns the change of the reservation to the given dates
+func NewReservationDatesChanged(reservation *dynamo.ReservationModel, fromDate string, toDate string) *ReservationDatesChanged {
+	return &ReservationDatesChanged{
+		ReservationID:    reservation.ReservationID,
+		UserID:           reservation.UserID,
+		ApartmentCode:    reservation.ApartmentCode,
+		FromDate:         fromDate,
+		ToDate:           toDate,
+		PreviousFromDate: reservation.FromDate,
+		PreviousToDate:   reservation.ToDate,
+	}
+}
+
+// EventType ...
+func (event *ReservationCreated) EventType() string { return ReservationCreatedType }
+
+// AggregateID ...
+func (event *ReservationCreated) AggregateID() string { return event.ReservationID }
+
+// GetApartmentCode ...
+func (event *ReservationCreated) GetApartmentCode() string { return event.ApartmentCode }
+
+// EventType ...
+func (event *ReservationCancelled) EventType() string { return ReservationCancelledType }
+
+// AggregateID ...
+func (event *ReservationCancelled) AggregateID() string { return event.ReservationID }
+
+// GetApartmentCode ...
+func (event *ReservationCancelled) GetApartmentCode() string { return event.ApartmentCode }
+
+// EventType ...
+func (event *ReservationDatesChanged) EventType() string { return ReservationDatesChangedType }
+
+// AggregateID ...
+func (event *ReservationDatesChanged) AggregateID() string { return event.ReservationID }
+
+// GetApartmentCode ...
+func (event *ReservationDatesChanged) GetApartmentCode() string { return event.ApartmentCode }
+
+// EventType ...
+func (event *UserDataCleared) EventType() string { return UserDataClearedType }
+
+// AggregateID ...
+func (event *UserDataCleared) AggregateID() string { return event.UserID }
+
+// EventType ...
+func (event *DepositReceived) EventType() string { return DepositReceivedType }
+
+// AggregateID ...
+func (event *DepositReceived) AggregateID() string { return event.ReservationID }
+
+// GetApartmentCode ...
+func (event *DepositReceived) GetApartmentCode() string { return event.ApartmentCode }
+
+// Envelope is the wire format of the events, the payload holds the JSON of the typed event
+type Envelope struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Version     int             `json:"version"`
+	OccurredAt  time.Time       `json:"occurredAt"`
+	AggregateID string          `json:"aggregateId"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// snsNotification is the body of an SQS message delivered from an SNS subscription without raw delivery
+type snsNotification struct {
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+}
+
+// NewEnvelope wraps the event with a new unique ID
+func NewEnvelope(event Event) (*Envelope, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		ID:          newEventID(),
+		Type:        event.EventType(),
+		Version:     EnvelopeVersion,
+		OccurredAt:  time.Now().UTC(),
+		AggregateID: event.AggregateID(),
+		Payload:     payload,
+	}, nil
+}
+
+// Marshal ...
+func (envelope *Envelope) Marshal() (string, error) {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// Decode returns the typed event of the envelope
+func (envelope *Envelope) Decode() (Event, error) {
+	factory, ok := eventFactories[envelope.Type]
+	if !ok {
+		return nil, errors.Wrap(ErrUnknownEventType, envelope.Type)
+	}
+
+	event := factory()
+	err := json.Unmarshal(envelope.Payload, event)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidEnvelope, err.Error())
+	}
+
+	return event, nil
+}
+
+// UnmarshalEnvelope decodes an envelope, SNS notifications delivered through SQS are unwrapped
+func UnmarshalEnvelope(body string) (*Envelope, error) {
+	notification := snsNotification{}
+	if err := json.Unmarshal([]byte(body), &notification); err == nil && notification.Type == "Notification" {
+		body = notification.Message
+	}
+
+	envelope := &Envelope{}
+	err := json.Unmarshal([]byte(body), envelope)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidEnvelope, err.Error())
+	}
+
+	if envelope.Type == "" || envelope.ID == "" {
+		return nil, errors.Wrap(ErrInvalidEnvelope, "missing id or type")
+	}
+
+	if envelope.Version != EnvelopeVersion {
+		return nil, errors.Wrap(ErrInvalidEnvelope, fmt.Sprintf("unsupported version: %d", envelope.Version))
+	}
+
+	return envelope, nil
+}
+
+func newEventID() string {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		panic(fmt.Sprintf("unable to generate event id: %v", err))
+	}
+
+	return hex.EncodeToString(id)
+}
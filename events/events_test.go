@@ -0,0 +1,104 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/pkg/errors"
+
+	"github.com/sylank/lavender-commons-go/dynamo"
+	"github.com/sylank/lavender-commons-go/messaging"
+	"github.com/sylank/lavender-commons-go/money"
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+type recordingSNS struct {
+	snsiface.SNSAPI
+
+	published []*sns.PublishInput
+}
+
+func (topic *recordingSNS) Publish(input *sns.PublishInput) (*sns.PublishOutput, error) {
+	topic.published = append(topic.published, input)
+
+	return &sns.PublishOutput{MessageId: aws.String("message-id")}, nil
+}
+
+func testReservation() *dynamo.ReservationModel {
+	return &dynamo.ReservationModel{
+		ReservationID:    "reservation-1",
+		UserID:           "user-1",
+		ApartmentCode:    "lavender",
+		FromDate:         "2026-07-10",
+		ToDate:           "2026-07-15",
+		CostValue:        money.FromMajor(125000, "HUF"),
+		DepositCostValue: money.FromMajor(37500, "HUF"),
+	}
+}
+
+func TestPublishAndDispatch(t *testing.T) {
+	topic := &recordingSNS{}
+	client := messaging.NewClientWithAPIs(nil, topic)
+	client.SetMessagingProperties(&props.MessagingProperties{
+		Topics: map[string]props.TopicInfo{"reservationEvents": {TopicArn: "arn:aws:sns:eu-central-1:123456789012:events"}},
+	})
+
+	err := NewSNSPublisher(client, "reservationEvents").Publish(NewReservationCreated(testReservation()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := topic.published[0]
+	if aws.StringValue(input.MessageAttributes[messaging.ApartmentCodeAttribute].StringValue) != "lavender" {
+		t.Errorf("apartment code attribute should be set: %v", input.MessageAttributes)
+	}
+
+	// The message arrives in SQS wrapped into an SNS notification
+	notification, _ := json.Marshal(map[string]string{"Type": "Notification", "Message": aws.StringValue(input.Message)})
+
+	var received *ReservationCreated
+	dispatcher := NewDispatcher()
+	dispatcher.Subscribe(ReservationCreatedType, func(ctx context.Context, envelope *Envelope, event Event) error {
+		received = event.(*ReservationCreated)
+		return nil
+	})
+	dispatcher.Subscribe(ReservationCancelledType, func(ctx context.Context, envelope *Envelope, event Event) error {
+		t.Error("cancellation handler should not be called")
+		return nil
+	})
+
+	err = dispatcher.HandleMessage(context.Background(), string(notification))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received == nil || received.ReservationID != "reservation-1" || received.CostValue != money.FromMajor(125000, "HUF") {
+		t.Errorf("unexpected event: %+v", received)
+	}
+}
+
+func TestDispatchErrors(t *testing.T) {
+	dispatcher := NewDispatcher()
+	dispatcher.Subscribe(DepositReceivedType, func(ctx context.Context, envelope *Envelope, event Event) error {
+		return errors.New("accounting unavailable")
+	})
+
+	envelope, _ := NewEnvelope(&DepositReceived{ReservationID: "reservation-1", Amount: money.FromMajor(37500, "HUF")})
+	if err := dispatcher.Dispatch(context.Background(), envelope); err == nil {
+		t.Error("handler error should be returned")
+	}
+
+	_, err := UnmarshalEnvelope(`{"id":"1","type":"ReservationCreated","version":2,"payload":{}}`)
+	if errors.Cause(err) != ErrInvalidEnvelope {
+		t.Errorf("expected invalid envelope error, got %v", err)
+	}
+
+	envelope.Type = "Unknown"
+	if _, err := envelope.Decode(); errors.Cause(err) != ErrUnknownEventType {
+		t.Errorf("expected unknown event type error, got %v", err)
+	}
+}
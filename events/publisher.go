@@ -0,0 +1,123 @@
+package events
+
+import (
+	"log"
+
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/sylank/lavender-commons-go/dynamo"
+	"github.com/sylank/lavender-commons-go/messaging"
+)
+
+// Publisher ...
+type Publisher interface {
+	Publish(event Event) error
+}
+
+// SNSPublisher publishes the events to a named topic with filter policy attributes
+type SNSPublisher struct {
+	client    *messaging.Client
+	topicName string
+}
+
+// SQSPublisher sends the events to a queue, on FIFO queues the events of an aggregate are ordered
+type SQSPublisher struct {
+	client    *messaging.Client
+	queueName string
+}
+
+// NewSNSPublisher ...
+func NewSNSPublisher(client *messaging.Client, topicName string) *SNSPublisher {
+	return &SNSPublisher{client: client, topicName: topicName}
+}
+
+// NewSQSPublisher ...
+func NewSQSPublisher(client *messaging.Client, queueName string) *SQSPublisher {
+	return &SQSPublisher{client: client, queueName: queueName}
+}
+
+// Publish ...
+func (publisher *SNSPublisher) Publish(event Event) error {
+	envelope, err := NewEnvelope(event)
+	if err != nil {
+		return err
+	}
+
+	return publisher.PublishEnvelope(envelope, apartmentCode(event))
+}
+
+// PublishEnvelope publishes an already created envelope, e.g. one stored in the outbox
+func (publisher *SNSPublisher) PublishEnvelope(envelope *Envelope, apartmentCode string) error {
+	body, err := envelope.Marshal()
+	if err != nil {
+		return err
+	}
+
+	_, err = publisher.client.PublishToTopic(publisher.topicName, body, &messaging.PublishOptions{
+		Subject:    envelope.Type,
+		Attributes: messaging.FilterAttributes(envelope.Type, apartmentCode),
+	})
+	if err != nil {
+		log.Println("Failed to publish event: "+envelope.Type+" id: "+envelope.ID, err)
+		return err
+	}
+
+	return nil
+}
+
+// Publish ...
+func (publisher *SQSPublisher) Publish(event Event) error {
+	envelope, err := NewEnvelope(event)
+	if err != nil {
+		return err
+	}
+
+	return publisher.PublishEnvelope(envelope, apartmentCode(event))
+}
+
+// PublishEnvelope sends an already created envelope, the envelope ID is used as deduplication ID
+func (publisher *SQSPublisher) PublishEnvelope(envelope *Envelope, apartmentCode string) error {
+	body, err := envelope.Marshal()
+	if err != nil {
+		return err
+	}
+
+	options := &messaging.SendOptions{
+		MessageAttributes: map[string]*sqs.MessageAttributeValue{
+			messaging.EventTypeAttribute: messaging.StringAttribute(envelope.Type),
+		},
+	}
+	if apartmentCode != "" {
+		options.MessageAttributes[messaging.ApartmentCodeAttribute] = messaging.StringAttribute(apartmentCode)
+	}
+	if messaging.IsFIFOQueue(publisher.queueName) {
+		options.MessageGroupID = envelope.AggregateID
+		options.DeduplicationID = envelope.ID
+	}
+
+	_, err = publisher.client.SendMessage(publisher.queueName, body, options)
+	if err != nil {
+		log.Println("Failed to send event: "+envelope.Type+" id: "+envelope.ID, err)
+		return err
+	}
+
+	return nil
+}
+
+// CancelReservation marks the reservation deleted and publishes a ReservationCancelled event
+func CancelReservation(publisher Publisher, reservation *dynamo.ReservationModel, reason string, table string) error {
+	err := dynamo.UpdateDeletedReservationStatus(reservation.ReservationID, reservation.UserID, table)
+	if err != nil {
+		return err
+	}
+
+	return publisher.Publish(NewReservationCancelled(reservation, reason))
+}
+
+func apartmentCode(event Event) string {
+	if apartmentEvent, ok := event.(ApartmentEvent); ok {
+		return apartmentEvent.GetApartmentCode()
+	}
+
+	return ""
+}
@@ -0,0 +1,123 @@
+package ical
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sylank/lavender-commons-go/dates"
+	"github.com/sylank/lavender-commons-go/dynamo"
+)
+
+// ContentType of the iCalendar feeds
+const ContentType = "text/calendar; charset=utf-8"
+
+// DefaultProductID is the PRODID of the exported feeds
+const DefaultProductID = "-//Lavender//Reservations//EN"
+
+// DefaultSummary is shown on the other channels for the exported reservations, guest data is never exported
+const DefaultSummary = "Reserved"
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+	maxLineLength  = 75
+)
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// ExportOptions ...
+type ExportOptions struct {
+	ProductID    string
+	CalendarName string
+	Summary      string
+	// UIDDomain is appended to the reservation IDs to make the UIDs globally unique
+	UIDDomain string
+	// Now is the DTSTAMP of the events, time.Now when zero
+	Now time.Time
+}
+
+// Export writes the non-deleted reservations of the apartment as an RFC 5545 feed of all-day events, reservations
+// with invalid dates are logged and left out so they do not break the feed
+func Export(apartmentCode string, reservations []dynamo.ReservationModel, location *time.Location, options ExportOptions) ([]byte, error) {
+	if options.ProductID == "" {
+		options.ProductID = DefaultProductID
+	}
+	if options.Summary == "" {
+		options.Summary = DefaultSummary
+	}
+	if options.Now.IsZero() {
+		options.Now = time.Now()
+	}
+
+	buffer := &bytes.Buffer{}
+	writeLine(buffer, "BEGIN:VCALENDAR")
+	writeLine(buffer, "VERSION:2.0")
+	writeLine(buffer, "PRODID:"+escapeText(options.ProductID))
+	writeLine(buffer, "CALSCALE:GREGORIAN")
+	writeLine(buffer, "METHOD:PUBLISH")
+	if options.CalendarName != "" {
+		writeLine(buffer, "X-WR-CALNAME:"+escapeText(options.CalendarName))
+	}
+
+	stamp := options.Now.UTC().Format(dateTimeLayout)
+	for _, reservation := range reservations {
+		if reservation.Deleted || reservation.ApartmentCode != apartmentCode {
+			continue
+		}
+
+		period, err := reservation.DateRange(location)
+		if err != nil {
+			log.Println("Skipping reservation with invalid dates: "+reservation.ReservationID, err)
+			continue
+		}
+
+		uid := reservation.ReservationID
+		if options.UIDDomain != "" {
+			uid += "@" + options.UIDDomain
+		}
+
+		writeEvent(buffer, uid, period, options.Summary, stamp)
+	}
+
+	writeLine(buffer, "END:VCALENDAR")
+
+	return buffer.Bytes(), nil
+}
+
+func writeEvent(buffer *bytes.Buffer, uid string, period dates.Range, summary string, stamp string) {
+	writeLine(buffer, "BEGIN:VEVENT")
+	writeLine(buffer, "UID:"+escapeText(uid))
+	writeLine(buffer, "DTSTAMP:"+stamp)
+	writeLine(buffer, "DTSTART;VALUE=DATE:"+period.Start.Format(dateLayout))
+	writeLine(buffer, "DTEND;VALUE=DATE:"+period.End.Format(dateLayout))
+	writeLine(buffer, "SUMMARY:"+escapeText(summary))
+	writeLine(buffer, "TRANSP:OPAQUE")
+	writeLine(buffer, "END:VEVENT")
+}
+
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+// writeLine folds the content line to 75 octets without splitting UTF-8 characters and ends it with CRLF
+func writeLine(buffer *bytes.Buffer, line string) {
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		buffer.WriteString(line[:cut])
+		buffer.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of the continuation line counts towards its length
+		limit = maxLineLength - 1
+	}
+
+	buffer.WriteString(line)
+	buffer.WriteString("\r\n")
+}
@@ -0,0 +1,170 @@
+package ical
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sylank/lavender-commons-go/dates"
+	"github.com/sylank/lavender-commons-go/dynamo"
+)
+
+var budapest, _ = dates.LoadLocation("")
+
+const airbnbFeed = "BEGIN:VCALENDAR\r\n" +
+	"PRODID:-//Airbnb Inc//Hosting Calendar 0.8.8//EN\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTEND;VALUE=DATE:20201012\r\n" +
+	"DTSTART;VALUE=DATE:20201009\r\n" +
+	"UID:1418fb94e984-2c9f4c4b3d1c@airbnb.com\r\n" +
+	"SUMMARY:Reserved\\, guest\r\n" +
+	" from Airbnb\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;TZID=Europe/Budapest:20201015T140000\r\n" +
+	"DURATION:P2DT20H\r\n" +
+	"UID:booking-1\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"TRIGGER:-PT15M\r\n" +
+	"DURATION:PT5M\r\n" +
+	"DESCRIPTION:Check-in reminder\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART:20201020T120000Z\r\n" +
+	"DTEND:20201022T080000Z\r\n" +
+	"UID:booking-2\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20201025\r\n" +
+	"UID:single-day\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	events, err := Parse(strings.NewReader(airbnbFeed), budapest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		uid       string
+		summary   string
+		startDate string
+		endDate   string
+	}{
+		{uid: "1418fb94e984-2c9f4c4b3d1c@airbnb.com", summary: "Reserved, guestfrom Airbnb", startDate: "2020-10-09", endDate: "2020-10-12"},
+		{uid: "booking-1", startDate: "2020-10-15", endDate: "2020-10-18"},
+		{uid: "single-day", startDate: "2020-10-25", endDate: "2020-10-26"},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), events)
+	}
+	for i, tC := range expected {
+		t.Run(tC.uid, func(t *testing.T) {
+			event := events[i]
+			days := event.Period.Days()
+			if event.UID != tC.uid || event.Summary != tC.summary || days.StartDate() != tC.startDate || days.EndDate() != tC.endDate {
+				t.Errorf("unexpected event: %+v, %s", event, days)
+			}
+		})
+	}
+
+	if _, err := Parse(strings.NewReader("<html></html>"), budapest); err == nil {
+		t.Errorf("expected error for invalid feed")
+	}
+}
+
+func TestExport(t *testing.T) {
+	reservations := []dynamo.ReservationModel{
+		{ReservationID: "r1", FromDate: "2020-10-01", ToDate: "2020-10-04", ApartmentCode: "A1"},
+		{ReservationID: "r2", FromDate: "2020-10-05", ToDate: "2020-10-06", ApartmentCode: "A1", Deleted: true},
+		{ReservationID: "r3", FromDate: "2020-10-05", ToDate: "2020-10-06", ApartmentCode: "B2"},
+		{ReservationID: "r4", FromDate: "invalid", ToDate: "2020-10-06", ApartmentCode: "A1"},
+	}
+
+	data, err := Export("A1", reservations, budapest, ExportOptions{
+		CalendarName: strings.Repeat("Lavender apartment; with a very long, escaped name ", 3),
+		UIDDomain:    "lavender.example.com",
+		Now:          time.Date(2020, 9, 1, 10, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed := string(data)
+	for _, line := range strings.Split(strings.TrimSuffix(feed, "\r\n"), "\r\n") {
+		if len(line) > maxLineLength {
+			t.Errorf("line is not folded: %q", line)
+		}
+		if strings.Contains(line, "\n") {
+			t.Errorf("line ends without CR: %q", line)
+		}
+	}
+	for _, expected := range []string{
+		"UID:r1@lavender.example.com\r\n",
+		"DTSTAMP:20200901T100000Z\r\n",
+		"DTSTART;VALUE=DATE:20201001\r\n",
+		"DTEND;VALUE=DATE:20201004\r\n",
+		`X-WR-CALNAME:Lavender apartment\; with a very long\, escaped name`,
+	} {
+		if !strings.Contains(feed, expected) {
+			t.Errorf("feed does not contain %q:\n%s", expected, feed)
+		}
+	}
+	if strings.Contains(feed, "r2") || strings.Contains(feed, "r3") || strings.Contains(feed, "r4") {
+		t.Errorf("deleted, invalid reservations and other apartments should not be exported:\n%s", feed)
+	}
+
+	events, err := Parse(bytes.NewReader(data), budapest)
+	if err != nil || len(events) != 1 || events[0].Period.StartDate() != "2020-10-01" || events[0].Period.EndDate() != "2020-10-04" {
+		t.Errorf("exported feed does not parse back: %+v, %v", events, err)
+	}
+}
+
+func TestWriteLineKeepsCharacters(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	line := "SUMMARY:" + strings.Repeat("árvíztűrő ", 20)
+	writeLine(buffer, line)
+
+	unfolded := strings.Replace(strings.TrimSuffix(buffer.String(), "\r\n"), "\r\n ", "", -1)
+	if unfolded != line {
+		t.Errorf("folding changed the line: %q", unfolded)
+	}
+}
+
+func TestImportFeeds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/airbnb.ics" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		w.Write([]byte(airbnbFeed))
+	}))
+	defer server.Close()
+
+	blocked, err := ImportFeeds(server.Client(), []string{server.URL + "/airbnb.ics"}, budapest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocked) != 3 {
+		t.Fatalf("unexpected blocked ranges: %v", blocked)
+	}
+
+	free, _ := dates.ParseRange("2020-10-12", "2020-10-15", budapest)
+	taken, _ := dates.ParseRange("2020-10-11", "2020-10-13", budapest)
+	if !IsAvailable(free, blocked) || IsAvailable(taken, blocked) {
+		t.Errorf("unexpected availability")
+	}
+
+	if _, err := ImportFeeds(server.Client(), []string{server.URL + "/airbnb.ics", server.URL + "/missing.ics"}, budapest); err == nil {
+		t.Errorf("a failing feed should fail the import")
+	}
+}
@@ -0,0 +1,335 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sylank/lavender-commons-go/dates"
+)
+
+// DefaultFetchTimeout limits the download of a feed when no client is given
+const DefaultFetchTimeout = 30 * time.Second
+
+var defaultClient = &http.Client{Timeout: DefaultFetchTimeout}
+
+// ErrInvalidFeed is returned for input which is not an iCalendar feed
+var ErrInvalidFeed = errors.New("invalid iCalendar feed")
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+// Event is a blocked period read from a feed
+type Event struct {
+	UID     string
+	Summary string
+	Period  dates.Range
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads the events of the feed, cancelled events are left out. Dates without a time zone are in the location.
+// Properties of the components nested in an event, e.g. VALARM, are ignored.
+func Parse(reader io.Reader, location *time.Location) ([]Event, error) {
+	lines, err := unfold(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, ErrInvalidFeed
+	}
+
+	events := []Event{}
+	var current []property
+	// components open at the line, the properties of the innermost VEVENT are collected
+	var components []string
+	for _, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			components = append(components, strings.ToUpper(prop.value))
+			if components[len(components)-1] == "VEVENT" {
+				current = nil
+			}
+		case "END":
+			// An END without BEGIN is ignored, the components left open inside the ended one are closed with it
+			open := lastIndex(components, strings.ToUpper(prop.value))
+			if open < 0 {
+				continue
+			}
+			components = components[:open]
+			if !strings.EqualFold(prop.value, "VEVENT") {
+				continue
+			}
+
+			event, ok, err := newEvent(current, location)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				events = append(events, event)
+			}
+		default:
+			if len(components) > 0 && components[len(components)-1] == "VEVENT" {
+				current = append(current, prop)
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// BlockedRanges returns the nights blocked by the events
+func BlockedRanges(events []Event) []dates.Range {
+	ranges := make([]dates.Range, 0, len(events))
+	for _, event := range events {
+		ranges = append(ranges, event.Period.Days())
+	}
+
+	return ranges
+}
+
+// IsAvailable returns true when the stay overlaps none of the blocked ranges
+func IsAvailable(stay dates.Range, blocked []dates.Range) bool {
+	for _, period := range blocked {
+		if stay.Overlaps(period) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FetchFeed downloads and parses the feed, a client with DefaultFetchTimeout is used when the client is nil
+func FetchFeed(client *http.Client, url string, location *time.Location) ([]Event, error) {
+	if client == nil {
+		client = defaultClient
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		log.Println("Unable to fetch iCalendar feed: "+url, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil, fmt.Errorf("fetching iCalendar feed %s: status %d", url, resp.StatusCode)
+	}
+
+	events, err := Parse(resp.Body, location)
+	if err != nil {
+		log.Println("Unable to parse iCalendar feed: "+url, err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ImportFeeds fetches every feed and returns the blocked ranges of all of them, a failing feed fails the import so
+// availability is never computed from partial data
+func ImportFeeds(client *http.Client, urls []string, location *time.Location) ([]dates.Range, error) {
+	blocked := []dates.Range{}
+	for _, url := range urls {
+		events, err := FetchFeed(client, url, location)
+		if err != nil {
+			return nil, err
+		}
+		blocked = append(blocked, BlockedRanges(events)...)
+	}
+
+	return blocked, nil
+}
+
+// unfold joins the continuation lines, lines may end with CRLF or LF
+func unfold(reader io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseLine splits "NAME;PARAM=VALUE:value", colons inside quoted parameter values are skipped
+func lastIndex(values []string, value string) int {
+	for i := len(values) - 1; i >= 0; i-- {
+		if values[i] == value {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func parseLine(line string) (property, error) {
+	quoted := false
+	separator := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			separator = i
+			break
+		}
+	}
+	if separator < 0 {
+		return property{}, errors.Wrap(ErrInvalidFeed, "content line without value: "+line)
+	}
+
+	parts := strings.Split(line[:separator], ";")
+	prop := property{
+		name:   strings.ToUpper(parts[0]),
+		params: map[string]string{},
+		value:  line[separator+1:],
+	}
+	for _, param := range parts[1:] {
+		if i := strings.Index(param, "="); i > 0 {
+			prop.params[strings.ToUpper(param[:i])] = strings.Trim(param[i+1:], `"`)
+		}
+	}
+
+	return prop, nil
+}
+
+func newEvent(props []property, location *time.Location) (Event, bool, error) {
+	event := Event{}
+	var start, end time.Time
+	var startAllDay, hasEnd bool
+	var duration time.Duration
+	for _, prop := range props {
+		var err error
+		switch prop.name {
+		case "UID":
+			event.UID = prop.value
+		case "SUMMARY":
+			event.Summary = textUnescaper.Replace(prop.value)
+		case "STATUS":
+			if strings.EqualFold(prop.value, "CANCELLED") {
+				return Event{}, false, nil
+			}
+		case "DTSTART":
+			start, startAllDay, err = parseDate(prop, location)
+		case "DTEND":
+			end, _, err = parseDate(prop, location)
+			hasEnd = true
+		case "DURATION":
+			duration, err = parseDuration(prop.value)
+		}
+		if err != nil {
+			return Event{}, false, errors.Wrap(err, "event "+event.UID)
+		}
+	}
+
+	if start.IsZero() {
+		return Event{}, false, errors.Wrap(ErrInvalidFeed, "event without DTSTART: "+event.UID)
+	}
+
+	switch {
+	case hasEnd:
+	case duration > 0 && startAllDay:
+		end = start.AddDate(0, 0, int(duration/(24*time.Hour)))
+	case duration > 0:
+		end = start.Add(duration)
+	case startAllDay:
+		// An all-day event without end lasts one day
+		end = start.AddDate(0, 0, 1)
+	default:
+		end = start
+	}
+
+	if !end.After(start) {
+		log.Println("Skipping event without duration: " + event.UID)
+		return Event{}, false, nil
+	}
+
+	period, err := dates.NewRange(start, end, startAllDay)
+	if err != nil {
+		return Event{}, false, err
+	}
+	event.Period = period
+
+	return event, true, nil
+}
+
+func parseDate(prop property, location *time.Location) (time.Time, bool, error) {
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(prop.value) == len("20060102") {
+		parsed, err := time.ParseInLocation("20060102", prop.value, location)
+		return parsed, true, err
+	}
+
+	if strings.HasSuffix(prop.value, "Z") {
+		parsed, err := time.Parse("20060102T150405Z", prop.value)
+		return parsed.In(location), false, err
+	}
+
+	zone := location
+	if tzid := prop.params["TZID"]; tzid != "" {
+		loaded, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		zone = loaded
+	}
+
+	parsed, err := time.ParseInLocation("20060102T150405", prop.value, zone)
+	return parsed.In(location), false, err
+}
+
+// parseDuration parses the day, hour, minute and second parts of an RFC 5545 duration, e.g. P1D or PT2H30M
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "+"), "P")
+	var total time.Duration
+	number := 0
+	inTime := false
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			number = number*10 + int(r-'0')
+			continue
+		case r == 'T':
+			inTime = true
+		case r == 'W':
+			total += time.Duration(number) * 7 * 24 * time.Hour
+		case r == 'D':
+			total += time.Duration(number) * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += time.Duration(number) * time.Hour
+		case r == 'M' && inTime:
+			total += time.Duration(number) * time.Minute
+		case r == 'S' && inTime:
+			total += time.Duration(number) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration: %s", value)
+		}
+		number = 0
+	}
+
+	return total, nil
+}
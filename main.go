@@ -1,5 +1,112 @@
 package main
 
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	formatter "github.com/sylank/lavender-commons-go/email"
+	"github.com/sylank/lavender-commons-go/utils"
+)
+
+const usage = `Usage:
+  lavender-commons-go preview -template <file> [-data <json file>] [-out <html file>] [-locale <locale>]
+  lavender-commons-go lint -template <file> [-required <placeholder,...>]
+`
+
 func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "preview":
+		err = runPreview(os.Args[2:])
+	case "lint":
+		err = runLint(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runPreview(args []string) error {
+	flags := flag.NewFlagSet("preview", flag.ExitOnError)
+	templateFile := flags.String("template", "", "email template file")
+	dataFile := flags.String("data", "", "JSON file with reservation data, sample data is used when empty")
+	outFile := flags.String("out", "", "output HTML file, stdout is used when empty")
+	locale := flags.String("locale", "", "locale used for formatting amounts")
+	flags.Parse(args)
+
+	if *templateFile == "" {
+		return fmt.Errorf("missing -template")
+	}
+
+	data := formatter.SampleTemplateData()
+	if *dataFile != "" {
+		var err error
+		data, err = formatter.ParseTemplateData(utils.ReadBytesFromFile(*dataFile))
+		if err != nil {
+			return fmt.Errorf("invalid data file %s: %v", *dataFile, err)
+		}
+	}
+	if *locale != "" {
+		data.Locale = *locale
+	}
+
+	template := formatter.EmailTemplate{}
+	template.InitEmail(*templateFile)
+	template.SetTemplateData(data)
+	text := template.GenerateEmailText()
+
+	if *outFile == "" {
+		fmt.Print(text)
+		return nil
+	}
+
+	err := ioutil.WriteFile(*outFile, []byte(text), 0644)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Preview written to: " + *outFile)
+	return nil
+}
+
+func runLint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	templateFile := flags.String("template", "", "email template file")
+	required := flags.String("required", "", "comma separated list of placeholders the template must contain")
+	flags.Parse(args)
+
+	if *templateFile == "" {
+		return fmt.Errorf("missing -template")
+	}
+
+	var requiredPlaceholders []string
+	if *required != "" {
+		requiredPlaceholders = strings.Split(*required, ",")
+	}
+
+	issues := formatter.LintTemplate(string(utils.ReadBytesFromFile(*templateFile)), requiredPlaceholders)
+	for _, issue := range issues {
+		fmt.Printf("%s: %s\n", *templateFile, issue)
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%d issue(s) found", len(issues))
+	}
 
+	fmt.Printf("%s: OK\n", *templateFile)
+	return nil
 }
@@ -0,0 +1,295 @@
+package messaging
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/pkg/errors"
+)
+
+// DefaultDelaySeconds is the delay of transactional emails when no options are given
+const DefaultDelaySeconds = 10
+
+// MaxBatchSize is the maximum number of entries of a SendMessageBatch call
+const MaxBatchSize = 10
+
+const defaultMaxBatchRetries = 3
+const batchRetryBackoff = 200 * time.Millisecond
+
+// PrecedingMessageFailedCode is the failure code of FIFO messages held back because an earlier message of
+// their group failed
+const PrecedingMessageFailedCode = "PrecedingMessageFailed"
+
+// ErrBatchPartialFailure is returned when some messages of a batch could not be sent
+var ErrBatchPartialFailure = errors.New("some messages of the batch were not sent")
+
+// SendOptions ...
+type SendOptions struct {
+	// DelaySeconds is sent as is, nil means the delay of the queue
+	DelaySeconds      *int64
+	MessageAttributes map[string]*sqs.MessageAttributeValue
+	// MessageGroupID and DeduplicationID are only valid for FIFO queues, the deduplication ID
+	// defaults to the hash of the body unless the queue has ContentBasedDeduplication enabled
+	MessageGroupID            string
+	DeduplicationID           string
+	ContentBasedDeduplication bool
+}
+
+// BatchMessage ...
+type BatchMessage struct {
+	Body    string
+	Options *SendOptions
+}
+
+// BatchFailure describes a message of the batch which could not be sent, Index refers to the input slice
+type BatchFailure struct {
+	Index       int
+	Code        string
+	Message     string
+	SenderFault bool
+}
+
+// BatchResult ...
+type BatchResult struct {
+	// MessageIDs has the same length as the input, failed messages have an empty ID
+	MessageIDs []string
+	Failed     []BatchFailure
+}
+
+// StringAttribute ...
+func StringAttribute(value string) *sqs.MessageAttributeValue {
+	return &sqs.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(value),
+	}
+}
+
+// NumberAttribute ...
+func NumberAttribute(value int64) *sqs.MessageAttributeValue {
+	return &sqs.MessageAttributeValue{
+		DataType:    aws.String("Number"),
+		StringValue: aws.String(strconv.FormatInt(value, 10)),
+	}
+}
+
+// SendMessage sends a single message to the queue and returns its message ID
+func (client *Client) SendMessage(queueName string, body string, options *SendOptions) (string, error) {
+	options, err := prepareSendOptions(queueName, body, options)
+	if err != nil {
+		log.Println("Invalid send options", err)
+		return "", err
+	}
+
+	qURL, err := client.QueueURL(queueName)
+	if err != nil {
+		log.Println("Failed to fetch queue URL")
+		return "", err
+	}
+
+	input := &sqs.SendMessageInput{
+		MessageBody: aws.String(body),
+		QueueUrl:    aws.String(qURL),
+	}
+	if options != nil {
+		input.DelaySeconds = options.DelaySeconds
+		input.MessageAttributes = options.MessageAttributes
+		input.MessageGroupId = optionalString(options.MessageGroupID)
+		input.MessageDeduplicationId = optionalString(options.DeduplicationID)
+	}
+
+	result, err := client.sendMessage(input)
+	if err != nil {
+		log.Println("Failed to send message", err)
+		return "", err
+	}
+
+	log.Println("Success", *result.MessageId)
+	return aws.StringValue(result.MessageId), nil
+}
+
+// SendMessageBatch sends the messages in chunks of MaxBatchSize. Failed entries which are not the
+// fault of the sender are retried up to MaxBatchRetries times; the remaining failures are reported
+// in the result together with ErrBatchPartialFailure. The later messages of a FIFO group with a failed
+// message are held back and retried after it, so the group keeps its order; they fail with
+// PrecedingMessageFailedCode when the failed message is not sent. Messages of the group sent in the same
+// request as the failed message are not held back.
+func (client *Client) SendMessageBatch(queueName string, messages []BatchMessage) (*BatchResult, error) {
+	result := &BatchResult{MessageIDs: make([]string, len(messages))}
+	if len(messages) == 0 {
+		return result, nil
+	}
+
+	qURL, err := client.QueueURL(queueName)
+	if err != nil {
+		log.Println("Failed to fetch queue URL")
+		return nil, err
+	}
+
+	failures := map[int]BatchFailure{}
+	// groups with a message which is not retried, their later messages are not sent
+	abandoned := map[string]bool{}
+	prepared := make([]BatchMessage, len(messages))
+	var pending []int
+	for i, message := range messages {
+		options, err := prepareSendOptions(queueName, message.Body, message.Options)
+		if err != nil {
+			failures[i] = BatchFailure{Index: i, Code: "InvalidOptions", Message: err.Error(), SenderFault: true}
+			if message.Options != nil && message.Options.MessageGroupID != "" {
+				abandoned[message.Options.MessageGroupID] = true
+			}
+			continue
+		}
+
+		prepared[i] = BatchMessage{Body: message.Body, Options: options}
+		pending = append(pending, i)
+	}
+
+	for attempt := 0; attempt <= client.getMaxBatchRetries() && len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			log.Println("Retrying failed batch entries, count: " + strconv.Itoa(len(pending)))
+			time.Sleep(time.Duration(attempt) * batchRetryBackoff)
+		}
+
+		blocked := map[string]bool{}
+		for group := range abandoned {
+			blocked[group] = true
+		}
+
+		var retry []int
+		for start := 0; start < len(pending); {
+			var chunk []int
+			for ; start < len(pending) && len(chunk) < MaxBatchSize; start++ {
+				index := pending[start]
+				group := groupOf(prepared[index])
+				if group != "" && blocked[group] {
+					failures[index] = BatchFailure{Index: index, Code: PrecedingMessageFailedCode, Message: "an earlier message of the group failed"}
+					if !abandoned[group] {
+						retry = append(retry, index)
+					}
+					continue
+				}
+				chunk = append(chunk, index)
+			}
+			if len(chunk) == 0 {
+				continue
+			}
+
+			for _, failure := range client.sendChunk(qURL, prepared, chunk, result) {
+				failures[failure.Index] = failure
+				group := groupOf(prepared[failure.Index])
+				if group != "" {
+					blocked[group] = true
+				}
+				if failure.SenderFault {
+					if group != "" {
+						abandoned[group] = true
+					}
+					continue
+				}
+				retry = append(retry, failure.Index)
+			}
+		}
+
+		sort.Ints(retry)
+		pending = retry
+	}
+
+	for i := range messages {
+		if failure, ok := failures[i]; ok && result.MessageIDs[i] == "" {
+			result.Failed = append(result.Failed, failure)
+		}
+	}
+
+	if len(result.Failed) > 0 {
+		log.Println("Failed to send batch entries, count: " + strconv.Itoa(len(result.Failed)))
+		return result, errors.Wrapf(ErrBatchPartialFailure, "%d of %d messages failed", len(result.Failed), len(messages))
+	}
+
+	return result, nil
+}
+
+// sendChunk sends the messages with the given indexes and returns the failed ones
+func (client *Client) sendChunk(qURL string, messages []BatchMessage, indexes []int, result *BatchResult) []BatchFailure {
+	var entries []*sqs.SendMessageBatchRequestEntry
+	for _, index := range indexes {
+		message := messages[index]
+		entry := &sqs.SendMessageBatchRequestEntry{
+			Id:          aws.String(strconv.Itoa(index)),
+			MessageBody: aws.String(message.Body),
+		}
+		if message.Options != nil {
+			entry.DelaySeconds = message.Options.DelaySeconds
+			entry.MessageAttributes = message.Options.MessageAttributes
+			entry.MessageGroupId = optionalString(message.Options.MessageGroupID)
+			entry.MessageDeduplicationId = optionalString(message.Options.DeduplicationID)
+		}
+		entries = append(entries, entry)
+	}
+
+	var failures []BatchFailure
+	output, err := client.sendMessageBatch(&sqs.SendMessageBatchInput{
+		QueueUrl: aws.String(qURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		log.Println("Failed to send message batch", err)
+		for _, index := range indexes {
+			failures = append(failures, BatchFailure{Index: index, Code: "RequestError", Message: err.Error()})
+		}
+		return failures
+	}
+
+	for _, successful := range output.Successful {
+		index, err := strconv.Atoi(aws.StringValue(successful.Id))
+		if err != nil {
+			continue
+		}
+		result.MessageIDs[index] = aws.StringValue(successful.MessageId)
+	}
+
+	for _, failed := range output.Failed {
+		index, err := strconv.Atoi(aws.StringValue(failed.Id))
+		if err != nil {
+			continue
+		}
+		failures = append(failures, BatchFailure{
+			Index:       index,
+			Code:        aws.StringValue(failed.Code),
+			Message:     aws.StringValue(failed.Message),
+			SenderFault: aws.BoolValue(failed.SenderFault),
+		})
+	}
+
+	return failures
+}
+
+func groupOf(message BatchMessage) string {
+	if message.Options == nil {
+		return ""
+	}
+
+	return message.Options.MessageGroupID
+}
+
+func (client *Client) getMaxBatchRetries() int {
+	if client.MaxBatchRetries < 0 {
+		return 0
+	}
+	if client.MaxBatchRetries == 0 {
+		return defaultMaxBatchRetries
+	}
+
+	return client.MaxBatchRetries
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return aws.String(value)
+}
@@ -0,0 +1,106 @@
+package messaging
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/pkg/errors"
+)
+
+func TestSendMessageBatch(t *testing.T) {
+	queue := newMemoryQueue()
+	queue.failures["retried"] = 2
+	queue.failures["rejected"] = -1
+	client := NewClientWithAPIs(queue, nil)
+
+	var messages []BatchMessage
+	for i := 0; i < 23; i++ {
+		messages = append(messages, BatchMessage{Body: fmt.Sprintf("reminder-%d", i)})
+	}
+	messages = append(messages, BatchMessage{Body: "retried"}, BatchMessage{Body: "rejected"})
+
+	result, err := client.SendMessageBatch("lavender-emails", messages)
+	if errors.Cause(err) != ErrBatchPartialFailure {
+		t.Fatalf("expected partial failure, got %v", err)
+	}
+
+	if len(result.Failed) != 1 || result.Failed[0].Index != 24 || !result.Failed[0].SenderFault {
+		t.Errorf("only the rejected message should fail: %+v", result.Failed)
+	}
+
+	if result.MessageIDs[23] == "" {
+		t.Error("retried message should be sent")
+	}
+
+	if count := len(queue.messages(memoryQueueURLPrefix + "lavender-emails")); count != 24 {
+		t.Errorf("expected 24 messages in the queue, got %d", count)
+	}
+
+	// 3 chunks, then one retry for retried+rejected and one more for retried
+	if queue.batchCalls != 5 {
+		t.Errorf("expected 5 batch calls, got %d", queue.batchCalls)
+	}
+}
+
+func TestSendMessageWithOptions(t *testing.T) {
+	queue := newMemoryQueue()
+	client := NewClientWithAPIs(queue, nil)
+
+	_, err := client.SendMessage("lavender-emails", "reminder", &SendOptions{
+		DelaySeconds:      aws.Int64(0),
+		MessageAttributes: map[string]*sqs.MessageAttributeValue{"campaign": StringAttribute("check-in")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := queue.messages(memoryQueueURLPrefix + "lavender-emails")
+	if len(messages) != 1 || aws.StringValue(messages[0].attributes["campaign"].StringValue) != "check-in" {
+		t.Errorf("message attributes should be sent: %+v", messages)
+	}
+}
+
+func TestSendMessageBatchKeepsFIFOOrder(t *testing.T) {
+	queue := newMemoryQueue()
+	queue.failures["g1-8"] = 1
+	queue.failures["g2-rejected"] = -1
+	client := NewClientWithAPIs(queue, nil)
+
+	// g1-8 fails at the end of the first chunk, the rest of g1 and g2 is in the second chunk
+	messages := []BatchMessage{{Body: "g2-rejected", Options: ReservationEventOptions("g2")}}
+	for i := 0; i < 12; i++ {
+		messages = append(messages, BatchMessage{Body: fmt.Sprintf("g1-%d", i), Options: ReservationEventOptions("g1")})
+	}
+	messages = append(messages,
+		BatchMessage{Body: "g2-later", Options: ReservationEventOptions("g2")},
+		BatchMessage{Body: "g3", Options: ReservationEventOptions("g3")},
+	)
+
+	result, err := client.SendMessageBatch("reservation-events.fifo", messages)
+	if errors.Cause(err) != ErrBatchPartialFailure {
+		t.Fatalf("expected partial failure, got %v", err)
+	}
+
+	if len(result.Failed) != 2 || result.Failed[0].Index != 0 || result.Failed[1].Index != 13 ||
+		result.Failed[1].Code != PrecedingMessageFailedCode {
+		t.Errorf("the rejected message and the later message of its group should fail: %+v", result.Failed)
+	}
+
+	var bodies []string
+	for _, message := range queue.messages(memoryQueueURLPrefix + "reservation-events.fifo") {
+		if message.groupID == "g1" {
+			bodies = append(bodies, message.body)
+		}
+	}
+	if len(bodies) != 12 {
+		t.Fatalf("expected 12 messages of g1, got %v", bodies)
+	}
+	for i, body := range bodies {
+		if body != fmt.Sprintf("g1-%d", i) {
+			t.Errorf("messages of g1 are out of order: %v", bodies)
+			break
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package messaging
+
+import (
+	"log"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+
+	props "github.com/sylank/lavender-commons-go/properties"
+	"github.com/sylank/lavender-commons-go/retry"
+)
+
+// Client holds the SQS and SNS clients, it is safe for concurrent use and should be created once
+type Client struct {
+	// MaxBatchRetries is the number of retries of failed batch entries, 0 means the default of 3, negative disables retries
+	MaxBatchRetries int
+	// RetryPolicy is applied to throttled and failed SQS and SNS calls
+	RetryPolicy retry.Policy
+
+	sqs sqsiface.SQSAPI
+	sns snsiface.SNSAPI
+
+	queueURLLock sync.RWMutex
+	queueURLs    map[string]string
+
+	messagingProperties *props.MessagingProperties
+}
+
+var defaultClient *Client
+var defaultClientLock sync.RWMutex
+
+// NewClient ...
+func NewClient(sess *session.Session) *Client {
+	return NewClientWithAPIs(sqs.New(sess, retry.AWSConfig()), sns.New(sess, retry.AWSConfig()))
+}
+
+// NewClientWithAPIs creates a client with the given, possibly fake, SQS and SNS implementations
+func NewClientWithAPIs(sqsAPI sqsiface.SQSAPI, snsAPI snsiface.SNSAPI) *Client {
+	return &Client{
+		RetryPolicy: retry.AWSPolicy(),
+		sqs:         sqsAPI,
+		sns:         snsAPI,
+		queueURLs:   map[string]string{},
+	}
+}
+
+// GetDefaultClient returns the client used by the package level functions, created from the shared config on first use
+func GetDefaultClient() *Client {
+	defaultClientLock.RLock()
+	client := defaultClient
+	defaultClientLock.RUnlock()
+	if client != nil {
+		return client
+	}
+
+	defaultClientLock.Lock()
+	defer defaultClientLock.Unlock()
+
+	if defaultClient == nil {
+		sess := session.Must(session.NewSessionWithOptions(session.Options{
+			SharedConfigState: session.SharedConfigEnable,
+		}))
+		defaultClient = NewClient(sess)
+	}
+
+	return defaultClient
+}
+
+// SetDefaultClient replaces the client used by the package level functions
+func SetDefaultClient(client *Client) {
+	defaultClientLock.Lock()
+	defer defaultClientLock.Unlock()
+
+	defaultClient = client
+}
+
+// SQS returns the underlying SQS client, e.g. for creating a Consumer
+func (client *Client) SQS() sqsiface.SQSAPI {
+	return client.sqs
+}
+
+// SNS returns the underlying SNS client
+func (client *Client) SNS() snsiface.SNSAPI {
+	return client.sns
+}
+
+// QueueURL returns the URL of the queue, the result is cached for the lifetime of the client
+func (client *Client) QueueURL(queueName string) (string, error) {
+	client.queueURLLock.RLock()
+	queueURL, ok := client.queueURLs[queueName]
+	client.queueURLLock.RUnlock()
+	if ok {
+		return queueURL, nil
+	}
+
+	err := client.RetryPolicy.Do(func() error {
+		var err error
+		queueURL, err = getQueueURL(queueName, client.sqs)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	client.queueURLLock.Lock()
+	client.queueURLs[queueName] = queueURL
+	client.queueURLLock.Unlock()
+
+	return queueURL, nil
+}
+
+// SendTransactionalEmail sends the message with the default delay of DefaultDelaySeconds
+func (client *Client) SendTransactionalEmail(message string, queueName string) error {
+	_, err := client.SendMessage(queueName, message, &SendOptions{DelaySeconds: aws.Int64(DefaultDelaySeconds)})
+
+	return err
+}
+
+// PublishMessage ...
+func (client *Client) PublishMessage(message string, subject string, topicArn string) error {
+	params := &sns.PublishInput{
+		Message:  aws.String(message),
+		TopicArn: aws.String(topicArn),
+		Subject:  aws.String(subject),
+	}
+
+	resp, err := client.publish(params)
+
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	log.Println(resp)
+	return nil
+}
@@ -0,0 +1,58 @@
+package messaging
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestClientCachesQueueURL(t *testing.T) {
+	queue := newMemoryQueue()
+	client := NewClientWithAPIs(queue, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.SendTransactionalEmail("message", "lavender-emails"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(queue.messages(memoryQueueURLPrefix+"lavender-emails")) != 20 {
+		t.Error("expected 20 messages in the queue")
+	}
+
+	// Concurrent first calls may race for the URL, later calls must be served from the cache
+	calls := queue.queueURLCalls
+	if err := client.SendTransactionalEmail("message", "lavender-emails"); err != nil {
+		t.Fatal(err)
+	}
+	if queue.queueURLCalls != calls {
+		t.Errorf("queue URL should be cached, GetQueueUrl calls: %d", queue.queueURLCalls)
+	}
+}
+
+func TestDefaultClientConcurrentAccess(t *testing.T) {
+	previous := GetDefaultClient()
+	defer SetDefaultClient(previous)
+
+	client := NewClientWithAPIs(newMemoryQueue(), nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetDefaultClient(client)
+		}()
+		go func() {
+			defer wg.Done()
+			if GetDefaultClient() == nil {
+				t.Error("default client should not be nil")
+			}
+		}()
+	}
+	wg.Wait()
+}
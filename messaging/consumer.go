@@ -0,0 +1,293 @@
+package messaging
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/pkg/errors"
+
+	"github.com/sylank/lavender-commons-go/retry"
+)
+
+const (
+	defaultMaxMessages       = 10
+	defaultWaitTimeSeconds   = 20
+	defaultVisibilityTimeout = 30
+	emptyReceiveBackoff      = time.Second
+)
+
+// MessageHandler processes a received message, the message is deleted from the queue when it returns nil
+type MessageHandler func(ctx context.Context, message *sqs.Message) error
+
+// ConsumerConfig ...
+type ConsumerConfig struct {
+	QueueURL string
+	// MaxMessages is the number of messages received at once, between 1 and 10
+	MaxMessages int64
+	// WaitTimeSeconds enables long polling, between 0 and 20
+	WaitTimeSeconds int64
+	// VisibilityTimeout in seconds, extended while the handler is running
+	VisibilityTimeout int64
+	// HeartbeatInterval defaults to half of the VisibilityTimeout
+	HeartbeatInterval time.Duration
+	// Concurrency is the number of handlers running at the same time
+	Concurrency int
+	// MaxReceiveCount moves a message to DeadLetterQueueURL when it was received more times, 0 disables it
+	MaxReceiveCount    int
+	DeadLetterQueueURL string
+}
+
+// Consumer receives messages from an SQS queue and passes them to a handler
+type Consumer struct {
+	// RetryPolicy is applied to throttled and failed SQS calls
+	RetryPolicy retry.Policy
+
+	svc     sqsiface.SQSAPI
+	config  ConsumerConfig
+	handler MessageHandler
+}
+
+// NewConsumer ...
+func NewConsumer(svc sqsiface.SQSAPI, config ConsumerConfig, handler MessageHandler) *Consumer {
+	if config.MaxMessages <= 0 || config.MaxMessages > 10 {
+		config.MaxMessages = defaultMaxMessages
+	}
+	if config.WaitTimeSeconds < 0 || config.WaitTimeSeconds > 20 {
+		config.WaitTimeSeconds = defaultWaitTimeSeconds
+	}
+	if config.VisibilityTimeout <= 0 {
+		config.VisibilityTimeout = defaultVisibilityTimeout
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = time.Duration(config.VisibilityTimeout) * time.Second / 2
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+
+	return &Consumer{
+		RetryPolicy: retry.AWSPolicy(),
+		svc:         svc,
+		config:      config,
+		handler:     handler,
+	}
+}
+
+// Start receives and processes messages until the context is cancelled
+func (consumer *Consumer) Start(ctx context.Context) error {
+	if consumer.config.MaxReceiveCount > 0 && consumer.config.DeadLetterQueueURL == "" {
+		return errors.New("DeadLetterQueueURL is required when MaxReceiveCount is set")
+	}
+
+	log.Println("Starting consumer, queue: " + consumer.config.QueueURL)
+	for {
+		processed, err := consumer.ReceiveAndProcess(ctx)
+		if ctx.Err() != nil {
+			log.Println("Consumer stopped, queue: " + consumer.config.QueueURL)
+			return nil
+		}
+		if err != nil {
+			log.Println("Failed to receive messages", err)
+		}
+
+		if err != nil || processed == 0 {
+			select {
+			case <-ctx.Done():
+				log.Println("Consumer stopped, queue: " + consumer.config.QueueURL)
+				return nil
+			case <-time.After(emptyReceiveBackoff):
+			}
+		}
+	}
+}
+
+// ReceiveAndProcess receives one batch of messages, processes them and deletes the successful ones.
+// It returns the number of received messages.
+func (consumer *Consumer) ReceiveAndProcess(ctx context.Context) (int, error) {
+	result, err := consumer.receiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(consumer.config.QueueURL),
+		MaxNumberOfMessages: aws.Int64(consumer.config.MaxMessages),
+		WaitTimeSeconds:     aws.Int64(consumer.config.WaitTimeSeconds),
+		VisibilityTimeout:   aws.Int64(consumer.config.VisibilityTimeout),
+		AttributeNames: []*string{
+			aws.String(sqs.MessageSystemAttributeNameApproximateReceiveCount),
+			aws.String(sqs.MessageSystemAttributeNameMessageGroupId),
+			aws.String(sqs.MessageSystemAttributeNameMessageDeduplicationId),
+		},
+		MessageAttributeNames: []*string{
+			aws.String(sqs.QueueAttributeNameAll),
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(result.Messages) == 0 {
+		return 0, nil
+	}
+
+	var lock sync.Mutex
+	var processed []*sqs.Message
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, consumer.config.Concurrency)
+
+	for _, message := range result.Messages {
+		wg.Add(1)
+		go func(message *sqs.Message) {
+			defer wg.Done()
+
+			// The visibility is extended while the message waits for a free slot as well
+			stopHeartbeat := consumer.startHeartbeat(ctx, message)
+			defer stopHeartbeat()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if consumer.processMessage(ctx, message) {
+				lock.Lock()
+				processed = append(processed, message)
+				lock.Unlock()
+			}
+		}(message)
+	}
+	wg.Wait()
+
+	consumer.deleteMessages(processed)
+
+	return len(result.Messages), nil
+}
+
+// processMessage returns true when the message can be deleted from the queue
+func (consumer *Consumer) processMessage(ctx context.Context, message *sqs.Message) bool {
+	messageID := aws.StringValue(message.MessageId)
+
+	if consumer.isDeadLetter(message) {
+		err := consumer.moveToDeadLetterQueue(ctx, message)
+		if err != nil {
+			log.Println("Failed to move message to dead-letter queue, messageId: "+messageID, err)
+			return false
+		}
+
+		log.Println("Message moved to dead-letter queue, messageId: " + messageID)
+		return true
+	}
+
+	err := consumer.callHandler(ctx, message)
+	if err != nil {
+		log.Println("Failed to process message, messageId: "+messageID, err)
+		return false
+	}
+
+	return true
+}
+
+func (consumer *Consumer) callHandler(ctx context.Context, message *sqs.Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("handler panic: %v", r)
+		}
+	}()
+
+	return consumer.handler(ctx, message)
+}
+
+func (consumer *Consumer) isDeadLetter(message *sqs.Message) bool {
+	if consumer.config.MaxReceiveCount <= 0 {
+		return false
+	}
+
+	receiveCount, err := strconv.Atoi(aws.StringValue(message.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]))
+	if err != nil {
+		return false
+	}
+
+	return receiveCount > consumer.config.MaxReceiveCount
+}
+
+// moveToDeadLetterQueue sends the message to the dead-letter queue, a FIFO dead-letter queue gets the group and
+// deduplication IDs of the original message, or its message ID when the source queue is not FIFO
+func (consumer *Consumer) moveToDeadLetterQueue(ctx context.Context, message *sqs.Message) error {
+	input := &sqs.SendMessageInput{
+		QueueUrl:          aws.String(consumer.config.DeadLetterQueueURL),
+		MessageBody:       message.Body,
+		MessageAttributes: message.MessageAttributes,
+	}
+	if IsFIFOQueue(consumer.config.DeadLetterQueueURL) {
+		input.MessageGroupId = systemAttribute(message, sqs.MessageSystemAttributeNameMessageGroupId)
+		input.MessageDeduplicationId = systemAttribute(message, sqs.MessageSystemAttributeNameMessageDeduplicationId)
+	}
+
+	_, err := consumer.sendMessage(ctx, input)
+
+	return err
+}
+
+func systemAttribute(message *sqs.Message, name string) *string {
+	if value := aws.StringValue(message.Attributes[name]); value != "" {
+		return aws.String(value)
+	}
+
+	return message.MessageId
+}
+
+func (consumer *Consumer) startHeartbeat(ctx context.Context, message *sqs.Message) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(consumer.config.HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, err := consumer.changeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+					QueueUrl:          aws.String(consumer.config.QueueURL),
+					ReceiptHandle:     message.ReceiptHandle,
+					VisibilityTimeout: aws.Int64(consumer.config.VisibilityTimeout),
+				})
+				if err != nil {
+					log.Println("Failed to extend visibility timeout, messageId: "+aws.StringValue(message.MessageId), err)
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+func (consumer *Consumer) deleteMessages(messages []*sqs.Message) {
+	if len(messages) == 0 {
+		return
+	}
+
+	var entries []*sqs.DeleteMessageBatchRequestEntry
+	for i, message := range messages {
+		entries = append(entries, &sqs.DeleteMessageBatchRequestEntry{
+			Id:            aws.String(strconv.Itoa(i)),
+			ReceiptHandle: message.ReceiptHandle,
+		})
+	}
+
+	// The messages are deleted even when the context was cancelled during processing
+	result, err := consumer.deleteMessageBatch(context.Background(), &sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(consumer.config.QueueURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		log.Println("Failed to delete messages", err)
+		return
+	}
+
+	for _, failed := range result.Failed {
+		log.Println("Failed to delete message, id: " + aws.StringValue(failed.Id) + " reason: " + aws.StringValue(failed.Message))
+	}
+}
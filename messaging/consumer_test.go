@@ -0,0 +1,210 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+const (
+	testQueueURL      = "https://sqs.eu-central-1.amazonaws.com/123456789012/lavender-emails"
+	testDeadLetterURL = "https://sqs.eu-central-1.amazonaws.com/123456789012/lavender-emails-dlq"
+)
+
+func TestConsumerDeletesProcessedMessages(t *testing.T) {
+	queue := newMemoryQueue()
+	queue.push(testQueueURL, "ok-1", 0)
+	queue.push(testQueueURL, "fail", 0)
+	queue.push(testQueueURL, "ok-2", 0)
+
+	var lock sync.Mutex
+	var handled []string
+	consumer := NewConsumer(queue, ConsumerConfig{QueueURL: testQueueURL, Concurrency: 2}, func(ctx context.Context, message *sqs.Message) error {
+		lock.Lock()
+		handled = append(handled, aws.StringValue(message.Body))
+		lock.Unlock()
+
+		if aws.StringValue(message.Body) == "fail" {
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+
+	received, err := consumer.ReceiveAndProcess(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received != 3 || len(handled) != 3 {
+		t.Errorf("expected 3 handled messages, received: %d, handled: %v", received, handled)
+	}
+
+	remaining := queue.messages(testQueueURL)
+	if len(remaining) != 1 || remaining[0].body != "fail" {
+		t.Errorf("only the failed message should remain in the queue: %v", remaining)
+	}
+
+	if queue.deleteCalls != 1 {
+		t.Errorf("expected one batch delete, got %d", queue.deleteCalls)
+	}
+}
+
+func TestConsumerMovesMessageToDeadLetterQueue(t *testing.T) {
+	queue := newMemoryQueue()
+	queue.push(testQueueURL, "poison", 3)
+
+	handlerCalled := false
+	consumer := NewConsumer(queue, ConsumerConfig{
+		QueueURL:           testQueueURL,
+		MaxReceiveCount:    3,
+		DeadLetterQueueURL: testDeadLetterURL,
+	}, func(ctx context.Context, message *sqs.Message) error {
+		handlerCalled = true
+		return nil
+	})
+
+	_, err := consumer.ReceiveAndProcess(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if handlerCalled {
+		t.Error("handler should not be called for dead-lettered messages")
+	}
+
+	if len(queue.messages(testQueueURL)) != 0 {
+		t.Error("message should be deleted from the source queue")
+	}
+
+	deadLetters := queue.messages(testDeadLetterURL)
+	if len(deadLetters) != 1 || deadLetters[0].body != "poison" {
+		t.Errorf("message should be moved to the dead-letter queue: %v", deadLetters)
+	}
+}
+
+func TestConsumerMovesMessageToFIFODeadLetterQueue(t *testing.T) {
+	const fifoQueueURL = testQueueURL + ".fifo"
+	const fifoDeadLetterURL = testDeadLetterURL + ".fifo"
+
+	queue := newMemoryQueue()
+	_, err := queue.SendMessageWithContext(context.Background(), &sqs.SendMessageInput{
+		QueueUrl:               aws.String(fifoQueueURL),
+		MessageBody:            aws.String("poison"),
+		MessageGroupId:         aws.String("reservation-1"),
+		MessageDeduplicationId: aws.String("event-1"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue.queues[fifoQueueURL][0].receiveCount = 3
+
+	consumer := NewConsumer(queue, ConsumerConfig{
+		QueueURL:           fifoQueueURL,
+		MaxReceiveCount:    3,
+		DeadLetterQueueURL: fifoDeadLetterURL,
+	}, func(ctx context.Context, message *sqs.Message) error {
+		return nil
+	})
+
+	if _, err := consumer.ReceiveAndProcess(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	deadLetters := queue.messages(fifoDeadLetterURL)
+	if len(deadLetters) != 1 || deadLetters[0].groupID != "reservation-1" || deadLetters[0].deduplicationID != "event-1" {
+		t.Errorf("message should keep its group and deduplication IDs: %+v", deadLetters)
+	}
+	if len(queue.messages(fifoQueueURL)) != 0 {
+		t.Error("message should be deleted from the source queue")
+	}
+}
+
+func TestConsumerExtendsVisibilityWhileHandlerRuns(t *testing.T) {
+	queue := newMemoryQueue()
+	queue.push(testQueueURL, "slow", 0)
+
+	consumer := NewConsumer(queue, ConsumerConfig{
+		QueueURL:          testQueueURL,
+		HeartbeatInterval: 10 * time.Millisecond,
+	}, func(ctx context.Context, message *sqs.Message) error {
+		time.Sleep(60 * time.Millisecond)
+		return errors.New("keep the message")
+	})
+
+	_, err := consumer.ReceiveAndProcess(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remaining := queue.messages(testQueueURL)
+	if len(remaining) != 1 || remaining[0].visibilityChanges == 0 {
+		t.Errorf("expected visibility timeout to be extended: %+v", remaining)
+	}
+}
+
+func TestConsumerLimitsConcurrency(t *testing.T) {
+	queue := newMemoryQueue()
+	for i := 0; i < 6; i++ {
+		queue.push(testQueueURL, "message", 0)
+	}
+
+	var lock sync.Mutex
+	running := 0
+	maxRunning := 0
+	consumer := NewConsumer(queue, ConsumerConfig{QueueURL: testQueueURL, Concurrency: 2}, func(ctx context.Context, message *sqs.Message) error {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return nil
+	})
+
+	_, err := consumer.ReceiveAndProcess(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if maxRunning != 2 {
+		t.Errorf("expected at most 2 concurrent handlers, got %d", maxRunning)
+	}
+}
+
+func TestConsumerStopsOnContextCancel(t *testing.T) {
+	queue := newMemoryQueue()
+	queue.push(testQueueURL, "message", 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer := NewConsumer(queue, ConsumerConfig{QueueURL: testQueueURL}, func(ctx context.Context, message *sqs.Message) error {
+		cancel()
+		return nil
+	})
+
+	done := make(chan error)
+	go func() { done <- consumer.Start(ctx) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("consumer did not stop")
+	}
+
+	if len(queue.messages(testQueueURL)) != 0 {
+		t.Error("message processed before cancellation should be deleted")
+	}
+}
@@ -0,0 +1,159 @@
+package messaging
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	formatter "github.com/sylank/lavender-commons-go/email"
+	"github.com/sylank/lavender-commons-go/money"
+)
+
+// EmailMessageVersion is the current version of the transactional email message schema
+const EmailMessageVersion = 1
+
+// ErrInvalidEmailMessage ...
+var ErrInvalidEmailMessage = errors.New("invalid email message")
+
+// ErrUnsupportedEmailMessageVersion ...
+var ErrUnsupportedEmailMessageVersion = errors.New("unsupported email message version")
+
+// EmailMessage is the body of the messages sent to the transactional email queue
+type EmailMessage struct {
+	Version        int                     `json:"version"`
+	Recipient      string                  `json:"recipient"`
+	TemplateName   string                  `json:"templateName"`
+	Locale         string                  `json:"locale"`
+	TemplateData   *formatter.TemplateData `json:"templateData"`
+	IdempotencyKey string                  `json:"idempotencyKey"`
+}
+
+// NewEmailMessage creates a message with the current schema version. The idempotency key is derived from the
+// reservation ID of the template data and the template name, so a rebuilt message is deduplicated; messages
+// without reservation get a random key, use NewEmailMessageWithKey to set it.
+func NewEmailMessage(recipient string, templateName string, templateData *formatter.TemplateData) (*EmailMessage, error) {
+	if templateData != nil && templateData.ReservationID != "" {
+		return NewEmailMessageWithKey(recipient, templateName, templateData, ReservationIdempotencyKey(templateData.ReservationID, templateName)), nil
+	}
+
+	key, err := randomIdempotencyKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEmailMessageWithKey(recipient, templateName, templateData, key), nil
+}
+
+// NewEmailMessageWithKey creates a message with the current schema version and the given idempotency key
+func NewEmailMessageWithKey(recipient string, templateName string, templateData *formatter.TemplateData, idempotencyKey string) *EmailMessage {
+	locale := money.DefaultLocale
+	if templateData != nil && templateData.Locale != "" {
+		locale = templateData.Locale
+	}
+
+	return &EmailMessage{
+		Version:        EmailMessageVersion,
+		Recipient:      recipient,
+		TemplateName:   templateName,
+		Locale:         locale,
+		TemplateData:   templateData,
+		IdempotencyKey: idempotencyKey,
+	}
+}
+
+// Validate ...
+func (message *EmailMessage) Validate() error {
+	if message.Version != EmailMessageVersion {
+		return errors.Wrapf(ErrUnsupportedEmailMessageVersion, "version: %d", message.Version)
+	}
+
+	var missing []string
+	if message.Recipient == "" {
+		missing = append(missing, "recipient")
+	} else if !strings.Contains(message.Recipient, "@") {
+		return errors.Wrapf(ErrInvalidEmailMessage, "invalid recipient: %s", message.Recipient)
+	}
+	if message.TemplateName == "" {
+		missing = append(missing, "templateName")
+	}
+	if message.Locale == "" {
+		missing = append(missing, "locale")
+	}
+	if message.IdempotencyKey == "" {
+		missing = append(missing, "idempotencyKey")
+	}
+	if message.TemplateData == nil {
+		missing = append(missing, "templateData")
+	}
+
+	if len(missing) > 0 {
+		return errors.Wrapf(ErrInvalidEmailMessage, "missing fields: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// Marshal validates the message and returns its JSON representation
+func (message *EmailMessage) Marshal() (string, error) {
+	err := message.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// DecodeEmailMessage decodes and validates a message received from the transactional email queue
+func DecodeEmailMessage(body string) (*EmailMessage, error) {
+	message := &EmailMessage{}
+	err := json.Unmarshal([]byte(body), message)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidEmailMessage, err.Error())
+	}
+
+	err = message.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// SendEmailMessage validates the message and sends it to the transactional email queue
+func SendEmailMessage(message *EmailMessage, queueName string) error {
+	body, err := message.Marshal()
+	if err != nil {
+		log.Println("Invalid email message", err)
+		return err
+	}
+
+	return SendTransactionalEmail(body, queueName)
+}
+
+// ReservationIdempotencyKey returns the idempotency key of the email of the reservation sent with the template
+func ReservationIdempotencyKey(reservationID string, templateName string) string {
+	sum := sha256.Sum256([]byte(reservationID + "/" + templateName))
+
+	return hex.EncodeToString(sum[:16])
+}
+
+func randomIdempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	_, err := rand.Read(key)
+	if err != nil {
+		log.Println("Unable to generate idempotency key", err)
+		return "", err
+	}
+
+	return hex.EncodeToString(key), nil
+}
@@ -0,0 +1,91 @@
+package messaging
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+
+	formatter "github.com/sylank/lavender-commons-go/email"
+)
+
+func TestEmailMessageRoundTrip(t *testing.T) {
+	message, err := NewEmailMessage("guest@example.com", "reservation-confirmation", formatter.SampleTemplateData())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := message.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeEmailMessage(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.IdempotencyKey != message.IdempotencyKey || decoded.TemplateName != message.TemplateName {
+		t.Errorf("decoded message does not match: %+v", decoded)
+	}
+
+	if decoded.TemplateData.CostValue != message.TemplateData.CostValue {
+		t.Errorf("expected cost %s, got %s", message.TemplateData.CostValue, decoded.TemplateData.CostValue)
+	}
+}
+
+func TestEmailMessageIdempotencyKey(t *testing.T) {
+	first, _ := NewEmailMessage("guest@example.com", "reservation-confirmation", formatter.SampleTemplateData())
+	rebuilt, _ := NewEmailMessage("guest@example.com", "reservation-confirmation", formatter.SampleTemplateData())
+	other, _ := NewEmailMessage("guest@example.com", "reservation-cancelled", formatter.SampleTemplateData())
+
+	if first.IdempotencyKey != rebuilt.IdempotencyKey {
+		t.Error("rebuilt message should keep the idempotency key")
+	}
+	if first.IdempotencyKey == other.IdempotencyKey {
+		t.Error("emails of other templates should have their own key")
+	}
+
+	data := formatter.SampleTemplateData()
+	data.ReservationID = ""
+	withoutReservation, err := NewEmailMessage("guest@example.com", "newsletter", data)
+	if err != nil || withoutReservation.IdempotencyKey == "" {
+		t.Errorf("expected random key, got %q, %v", withoutReservation.IdempotencyKey, err)
+	}
+}
+
+func TestDecodeEmailMessageErrors(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		body     string
+		expected error
+	}{
+		{
+			desc:     "Malformed JSON",
+			body:     "not json",
+			expected: ErrInvalidEmailMessage,
+		},
+		{
+			desc:     "Unsupported version",
+			body:     `{"version":2,"recipient":"guest@example.com","templateName":"t","locale":"hu-HU","templateData":{},"idempotencyKey":"k"}`,
+			expected: ErrUnsupportedEmailMessageVersion,
+		},
+		{
+			desc:     "Missing fields",
+			body:     `{"version":1,"recipient":"guest@example.com"}`,
+			expected: ErrInvalidEmailMessage,
+		},
+		{
+			desc:     "Invalid recipient",
+			body:     `{"version":1,"recipient":"guest","templateName":"t","locale":"hu-HU","templateData":{},"idempotencyKey":"k"}`,
+			expected: ErrInvalidEmailMessage,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := DecodeEmailMessage(tC.body)
+			if errors.Cause(err) != tC.expected {
+				t.Errorf("expected %v, got %v", tC.expected, err)
+			}
+		})
+	}
+}
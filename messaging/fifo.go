@@ -0,0 +1,74 @@
+package messaging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FIFOQueueSuffix is the mandatory suffix of FIFO queue names
+const FIFOQueueSuffix = ".fifo"
+
+// ErrMissingMessageGroupID is returned when a message is sent to a FIFO queue without a group ID
+var ErrMissingMessageGroupID = errors.New("message group ID is required for FIFO queues")
+
+// ErrFIFOOptionsOnStandardQueue is returned when a group or deduplication ID is set for a standard queue
+var ErrFIFOOptionsOnStandardQueue = errors.New("message group and deduplication IDs are only supported by FIFO queues")
+
+// IsFIFOQueue ...
+func IsFIFOQueue(queueName string) bool {
+	return strings.HasSuffix(queueName, FIFOQueueSuffix)
+}
+
+// ContentDeduplicationID returns the SHA-256 hash of the body, the same value SQS uses for content-based deduplication
+func ContentDeduplicationID(body string) string {
+	hash := sha256.Sum256([]byte(body))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// ReservationEventOptions orders the events of a reservation by using its ID as message group
+func ReservationEventOptions(reservationID string) *SendOptions {
+	return &SendOptions{MessageGroupID: reservationID}
+}
+
+// SendReservationEvent sends a reservation create/cancel event. On FIFO queues the events of the same
+// reservation are delivered in order and duplicates within the deduplication interval are dropped.
+func (client *Client) SendReservationEvent(queueName string, reservationID string, body string) (string, error) {
+	if !IsFIFOQueue(queueName) {
+		log.Println("Reservation events sent to a standard queue are not ordered, queue: " + queueName)
+		return client.SendMessage(queueName, body, nil)
+	}
+
+	return client.SendMessage(queueName, body, ReservationEventOptions(reservationID))
+}
+
+// prepareSendOptions checks and completes the options for the type of the queue
+func prepareSendOptions(queueName string, body string, options *SendOptions) (*SendOptions, error) {
+	if !IsFIFOQueue(queueName) {
+		if options != nil && (options.MessageGroupID != "" || options.DeduplicationID != "") {
+			return nil, errors.Wrapf(ErrFIFOOptionsOnStandardQueue, "queue: %s", queueName)
+		}
+
+		return options, nil
+	}
+
+	if options == nil || options.MessageGroupID == "" {
+		return nil, errors.Wrapf(ErrMissingMessageGroupID, "queue: %s", queueName)
+	}
+
+	prepared := *options
+	if prepared.DelaySeconds != nil {
+		// FIFO queues only support a queue level delay
+		log.Println("Per-message delay is ignored for FIFO queue: " + queueName)
+		prepared.DelaySeconds = nil
+	}
+	if prepared.DeduplicationID == "" && !prepared.ContentBasedDeduplication {
+		prepared.DeduplicationID = ContentDeduplicationID(body)
+	}
+
+	return &prepared, nil
+}
@@ -0,0 +1,81 @@
+package messaging
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+)
+
+func TestSendReservationEventToFIFOQueue(t *testing.T) {
+	queue := newMemoryQueue()
+	client := NewClientWithAPIs(queue, nil)
+
+	for _, body := range []string{`{"type":"created"}`, `{"type":"cancelled"}`, `{"type":"cancelled"}`} {
+		if _, err := client.SendReservationEvent("reservation-events.fifo", "reservation-1", body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	messages := queue.messages(memoryQueueURLPrefix + "reservation-events.fifo")
+	if len(messages) != 2 {
+		t.Fatalf("duplicate event should be dropped, got %d messages", len(messages))
+	}
+
+	for _, message := range messages {
+		if message.groupID != "reservation-1" || message.deduplicationID != ContentDeduplicationID(message.body) {
+			t.Errorf("unexpected FIFO attributes: %+v", message)
+		}
+	}
+}
+
+func TestFIFOSendOptions(t *testing.T) {
+	queue := newMemoryQueue()
+	client := NewClientWithAPIs(queue, nil)
+
+	_, err := client.SendMessage("reservation-events.fifo", "body", nil)
+	if errors.Cause(err) != ErrMissingMessageGroupID {
+		t.Errorf("expected missing group ID error, got %v", err)
+	}
+
+	_, err = client.SendMessage("lavender-emails", "body", ReservationEventOptions("reservation-1"))
+	if errors.Cause(err) != ErrFIFOOptionsOnStandardQueue {
+		t.Errorf("expected standard queue error, got %v", err)
+	}
+
+	// The transactional email delay is dropped for FIFO queues instead of being rejected by SQS
+	_, err = client.SendMessage("emails.fifo", "body", &SendOptions{
+		DelaySeconds:    aws.Int64(DefaultDelaySeconds),
+		MessageGroupID:  "user-1",
+		DeduplicationID: "explicit-id",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := queue.messages(memoryQueueURLPrefix + "emails.fifo")
+	if len(messages) != 1 || messages[0].deduplicationID != "explicit-id" {
+		t.Errorf("explicit deduplication ID should be kept: %+v", messages)
+	}
+}
+
+func TestSendMessageBatchToFIFOQueue(t *testing.T) {
+	queue := newMemoryQueue()
+	client := NewClientWithAPIs(queue, nil)
+
+	result, err := client.SendMessageBatch("reservation-events.fifo", []BatchMessage{
+		{Body: "created", Options: ReservationEventOptions("reservation-1")},
+		{Body: "no group"},
+	})
+	if errors.Cause(err) != ErrBatchPartialFailure {
+		t.Fatalf("expected partial failure, got %v", err)
+	}
+
+	if len(result.Failed) != 1 || result.Failed[0].Index != 1 || result.Failed[0].Code != "InvalidOptions" {
+		t.Errorf("message without group ID should fail: %+v", result.Failed)
+	}
+
+	if result.MessageIDs[0] == "" {
+		t.Error("message with group ID should be sent")
+	}
+}
@@ -0,0 +1,235 @@
+package messaging
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+type memoryMessage struct {
+	id                string
+	body              string
+	attributes        map[string]*sqs.MessageAttributeValue
+	groupID           string
+	deduplicationID   string
+	receiveCount      int
+	receiptHandle     string
+	invisibleUntil    time.Time
+	visibilityChanges int
+}
+
+// memoryQueue is an in-memory fake of the SQS API, only the calls used by the package are implemented
+type memoryQueue struct {
+	sqsiface.SQSAPI
+
+	lock          sync.Mutex
+	queues        map[string][]*memoryMessage
+	nextID        int
+	deleteCalls   int
+	receiveCalls  int
+	queueURLCalls int
+	batchCalls    int
+	// failures makes sending a body fail the given number of times, a negative value fails it with sender fault
+	failures map[string]int
+}
+
+const memoryQueueURLPrefix = "https://sqs.local/123456789012/"
+
+func newMemoryQueue() *memoryQueue {
+	return &memoryQueue{queues: map[string][]*memoryMessage{}, failures: map[string]int{}}
+}
+
+func (queue *memoryQueue) push(queueURL string, body string, receiveCount int) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	queue.nextID++
+	queue.queues[queueURL] = append(queue.queues[queueURL], &memoryMessage{
+		id:           strconv.Itoa(queue.nextID),
+		body:         body,
+		receiveCount: receiveCount,
+	})
+}
+
+func (queue *memoryQueue) messages(queueURL string) []*memoryMessage {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	return append([]*memoryMessage(nil), queue.queues[queueURL]...)
+}
+
+func (queue *memoryQueue) ReceiveMessageWithContext(ctx aws.Context, input *sqs.ReceiveMessageInput, opts ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	queue.receiveCalls++
+	now := time.Now()
+	output := &sqs.ReceiveMessageOutput{}
+	for _, message := range queue.queues[aws.StringValue(input.QueueUrl)] {
+		if int64(len(output.Messages)) >= aws.Int64Value(input.MaxNumberOfMessages) {
+			break
+		}
+		if message.invisibleUntil.After(now) {
+			continue
+		}
+
+		message.receiveCount++
+		message.receiptHandle = fmt.Sprintf("%s-%d", message.id, message.receiveCount)
+		message.invisibleUntil = now.Add(time.Duration(aws.Int64Value(input.VisibilityTimeout)) * time.Second)
+		attributes := map[string]*string{
+			sqs.MessageSystemAttributeNameApproximateReceiveCount: aws.String(strconv.Itoa(message.receiveCount)),
+		}
+		if message.groupID != "" {
+			attributes[sqs.MessageSystemAttributeNameMessageGroupId] = aws.String(message.groupID)
+			attributes[sqs.MessageSystemAttributeNameMessageDeduplicationId] = aws.String(message.deduplicationID)
+		}
+		output.Messages = append(output.Messages, &sqs.Message{
+			MessageId:         aws.String(message.id),
+			Body:              aws.String(message.body),
+			ReceiptHandle:     aws.String(message.receiptHandle),
+			MessageAttributes: message.attributes,
+			Attributes:        attributes,
+		})
+	}
+
+	return output, nil
+}
+
+func (queue *memoryQueue) DeleteMessageBatchWithContext(ctx aws.Context, input *sqs.DeleteMessageBatchInput, opts ...request.Option) (*sqs.DeleteMessageBatchOutput, error) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	queue.deleteCalls++
+	queueURL := aws.StringValue(input.QueueUrl)
+	output := &sqs.DeleteMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		deleted := false
+		for i, message := range queue.queues[queueURL] {
+			if message.receiptHandle == aws.StringValue(entry.ReceiptHandle) {
+				queue.queues[queueURL] = append(queue.queues[queueURL][:i], queue.queues[queueURL][i+1:]...)
+				deleted = true
+				break
+			}
+		}
+
+		if deleted {
+			output.Successful = append(output.Successful, &sqs.DeleteMessageBatchResultEntry{Id: entry.Id})
+		} else {
+			output.Failed = append(output.Failed, &sqs.BatchResultErrorEntry{Id: entry.Id, Code: aws.String("ReceiptHandleIsInvalid")})
+		}
+	}
+
+	return output, nil
+}
+
+func (queue *memoryQueue) ChangeMessageVisibilityWithContext(ctx aws.Context, input *sqs.ChangeMessageVisibilityInput, opts ...request.Option) (*sqs.ChangeMessageVisibilityOutput, error) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	for _, message := range queue.queues[aws.StringValue(input.QueueUrl)] {
+		if message.receiptHandle == aws.StringValue(input.ReceiptHandle) {
+			message.visibilityChanges++
+			message.invisibleUntil = time.Now().Add(time.Duration(aws.Int64Value(input.VisibilityTimeout)) * time.Second)
+			return &sqs.ChangeMessageVisibilityOutput{}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("receipt handle not found: %s", aws.StringValue(input.ReceiptHandle))
+}
+
+func (queue *memoryQueue) SendMessageWithContext(ctx aws.Context, input *sqs.SendMessageInput, opts ...request.Option) (*sqs.SendMessageOutput, error) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	queueURL := aws.StringValue(input.QueueUrl)
+	if strings.HasSuffix(queueURL, ".fifo") {
+		if input.MessageGroupId == nil {
+			return nil, fmt.Errorf("MessageGroupId is required for FIFO queues")
+		}
+		if input.DelaySeconds != nil {
+			return nil, fmt.Errorf("per-message DelaySeconds is not supported for FIFO queues")
+		}
+		for _, message := range queue.queues[queueURL] {
+			if message.deduplicationID == aws.StringValue(input.MessageDeduplicationId) {
+				return &sqs.SendMessageOutput{MessageId: aws.String(message.id)}, nil
+			}
+		}
+	}
+
+	queue.nextID++
+	message := &memoryMessage{
+		id:              strconv.Itoa(queue.nextID),
+		body:            aws.StringValue(input.MessageBody),
+		attributes:      input.MessageAttributes,
+		groupID:         aws.StringValue(input.MessageGroupId),
+		deduplicationID: aws.StringValue(input.MessageDeduplicationId),
+	}
+	if input.DelaySeconds != nil {
+		message.invisibleUntil = time.Now().Add(time.Duration(aws.Int64Value(input.DelaySeconds)) * time.Second)
+	}
+	queue.queues[queueURL] = append(queue.queues[queueURL], message)
+
+	return &sqs.SendMessageOutput{MessageId: aws.String(message.id)}, nil
+}
+
+func (queue *memoryQueue) SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	return queue.SendMessageWithContext(aws.BackgroundContext(), input)
+}
+
+func (queue *memoryQueue) GetQueueUrl(input *sqs.GetQueueUrlInput) (*sqs.GetQueueUrlOutput, error) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	queue.queueURLCalls++
+
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String(memoryQueueURLPrefix + aws.StringValue(input.QueueName))}, nil
+}
+
+func (queue *memoryQueue) SendMessageBatch(input *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+	queue.lock.Lock()
+	queue.batchCalls++
+	if len(input.Entries) > 10 {
+		queue.lock.Unlock()
+		return nil, fmt.Errorf("too many entries in batch: %d", len(input.Entries))
+	}
+
+	output := &sqs.SendMessageBatchOutput{}
+	var sent []*sqs.SendMessageBatchRequestEntry
+	for _, entry := range input.Entries {
+		body := aws.StringValue(entry.MessageBody)
+		if failures := queue.failures[body]; failures != 0 {
+			if failures > 0 {
+				queue.failures[body]--
+			}
+			output.Failed = append(output.Failed, &sqs.BatchResultErrorEntry{
+				Id:          entry.Id,
+				Code:        aws.String("InternalError"),
+				SenderFault: aws.Bool(failures < 0),
+			})
+			continue
+		}
+		sent = append(sent, entry)
+	}
+	queue.lock.Unlock()
+
+	for _, entry := range sent {
+		result, _ := queue.SendMessageWithContext(aws.BackgroundContext(), &sqs.SendMessageInput{
+			QueueUrl:               input.QueueUrl,
+			MessageBody:            entry.MessageBody,
+			DelaySeconds:           entry.DelaySeconds,
+			MessageAttributes:      entry.MessageAttributes,
+			MessageGroupId:         entry.MessageGroupId,
+			MessageDeduplicationId: entry.MessageDeduplicationId,
+		})
+		output.Successful = append(output.Successful, &sqs.SendMessageBatchResultEntry{Id: entry.Id, MessageId: result.MessageId})
+	}
+
+	return output, nil
+}
@@ -0,0 +1,96 @@
+package messaging
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+func (client *Client) sendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	var output *sqs.SendMessageOutput
+	err := client.RetryPolicy.Do(func() error {
+		var err error
+		output, err = client.sqs.SendMessage(input)
+		return err
+	})
+
+	return output, err
+}
+
+func (client *Client) sendMessageBatch(input *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+	var output *sqs.SendMessageBatchOutput
+	err := client.RetryPolicy.Do(func() error {
+		var err error
+		output, err = client.sqs.SendMessageBatch(input)
+		return err
+	})
+
+	return output, err
+}
+
+func (client *Client) publish(input *sns.PublishInput) (*sns.PublishOutput, error) {
+	var output *sns.PublishOutput
+	err := client.RetryPolicy.Do(func() error {
+		var err error
+		output, err = client.sns.Publish(input)
+		return err
+	})
+
+	return output, err
+}
+
+func (client *Client) checkIfPhoneNumberIsOptedOut(input *sns.CheckIfPhoneNumberIsOptedOutInput) (*sns.CheckIfPhoneNumberIsOptedOutOutput, error) {
+	var output *sns.CheckIfPhoneNumberIsOptedOutOutput
+	err := client.RetryPolicy.Do(func() error {
+		var err error
+		output, err = client.sns.CheckIfPhoneNumberIsOptedOut(input)
+		return err
+	})
+
+	return output, err
+}
+
+func (consumer *Consumer) receiveMessage(ctx context.Context, input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	var output *sqs.ReceiveMessageOutput
+	err := consumer.RetryPolicy.DoWithContext(ctx, func() error {
+		var err error
+		output, err = consumer.svc.ReceiveMessageWithContext(ctx, input)
+		return err
+	})
+
+	return output, err
+}
+
+func (consumer *Consumer) sendMessage(ctx context.Context, input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	var output *sqs.SendMessageOutput
+	err := consumer.RetryPolicy.DoWithContext(ctx, func() error {
+		var err error
+		output, err = consumer.svc.SendMessageWithContext(ctx, input)
+		return err
+	})
+
+	return output, err
+}
+
+func (consumer *Consumer) changeMessageVisibility(ctx context.Context, input *sqs.ChangeMessageVisibilityInput) (*sqs.ChangeMessageVisibilityOutput, error) {
+	var output *sqs.ChangeMessageVisibilityOutput
+	err := consumer.RetryPolicy.DoWithContext(ctx, func() error {
+		var err error
+		output, err = consumer.svc.ChangeMessageVisibilityWithContext(ctx, input)
+		return err
+	})
+
+	return output, err
+}
+
+func (consumer *Consumer) deleteMessageBatch(ctx context.Context, input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+	var output *sqs.DeleteMessageBatchOutput
+	err := consumer.RetryPolicy.DoWithContext(ctx, func() error {
+		var err error
+		output, err = consumer.svc.DeleteMessageBatchWithContext(ctx, input)
+		return err
+	})
+
+	return output, err
+}
@@ -0,0 +1,170 @@
+package messaging
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/pkg/errors"
+
+	"github.com/sylank/lavender-commons-go/dynamo"
+)
+
+// DefaultCountryCode is used for phone numbers given in national format
+const DefaultCountryCode = "36"
+
+// SMS types supported by SNS
+const (
+	SMSTypeTransactional = "Transactional"
+	SMSTypePromotional   = "Promotional"
+)
+
+// CheckInReminderTemplate is the default text of the check-in reminder, placeholders use the email template syntax
+const CheckInReminderTemplate = "Dear <name>, we are expecting you at <apartment> on <fromDate>. Reservation: <reservationId>"
+
+const gsmSegmentLength = 160
+const unicodeSegmentLength = 70
+
+// ErrInvalidPhoneNumber ...
+var ErrInvalidPhoneNumber = errors.New("invalid phone number")
+
+// ErrPhoneOptedOut is returned when the owner of the phone number opted out of receiving SMS
+var ErrPhoneOptedOut = errors.New("phone number opted out of SMS")
+
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "", "/", "")
+
+// trunkPrefixes holds the national dialing prefixes which differ from a single zero
+var trunkPrefixes = map[string]string{
+	"36": "06",
+}
+
+// SMSOptions ...
+type SMSOptions struct {
+	// SenderID is shown as the sender in the countries supporting alphanumeric sender IDs
+	SenderID string
+	// Type is SMSTypeTransactional or SMSTypePromotional, defaults to transactional
+	Type string
+}
+
+// NormalizePhoneNumber converts a phone number to E.164 format, numbers in national format get the country code
+func NormalizePhoneNumber(phone string, countryCode string) (string, error) {
+	normalized := phoneSeparators.Replace(strings.TrimSpace(phone))
+
+	switch {
+	case strings.HasPrefix(normalized, "+"):
+	case strings.HasPrefix(normalized, "00"):
+		normalized = "+" + normalized[2:]
+	default:
+		trunkPrefix, ok := trunkPrefixes[countryCode]
+		if !ok {
+			trunkPrefix = "0"
+		}
+		if !strings.HasPrefix(normalized, trunkPrefix) {
+			return "", errors.Wrap(ErrInvalidPhoneNumber, phone)
+		}
+		normalized = "+" + countryCode + normalized[len(trunkPrefix):]
+	}
+
+	if !e164Pattern.MatchString(normalized) {
+		return "", errors.Wrap(ErrInvalidPhoneNumber, phone)
+	}
+
+	return normalized, nil
+}
+
+// RenderSMS replaces the <placeholder> values of the template
+func RenderSMS(template string, values map[string]string) string {
+	var pairs []string
+	for name, value := range values {
+		pairs = append(pairs, "<"+name+">", value)
+	}
+
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// SMSSegments returns the number of SMS parts the text is split into
+func SMSSegments(text string) int {
+	segmentLength := gsmSegmentLength
+	for _, r := range text {
+		if r > 127 {
+			segmentLength = unicodeSegmentLength
+			break
+		}
+	}
+
+	length := utf8.RuneCountInString(text)
+	if length <= segmentLength {
+		return 1
+	}
+
+	// Concatenated messages lose 7 characters per part to the header
+	partLength := segmentLength - 7
+
+	return (length + partLength - 1) / partLength
+}
+
+// SendSMS publishes the text directly to the phone number, numbers that opted out are not sent to
+func (client *Client) SendSMS(phone string, text string, options *SMSOptions) (string, error) {
+	phoneNumber, err := NormalizePhoneNumber(phone, DefaultCountryCode)
+	if err != nil {
+		log.Println("Unable to send SMS", err)
+		return "", err
+	}
+
+	optOut, err := client.checkIfPhoneNumberIsOptedOut(&sns.CheckIfPhoneNumberIsOptedOutInput{
+		PhoneNumber: aws.String(phoneNumber),
+	})
+	if err != nil {
+		log.Println("Unable to check SMS opt-out", err)
+		return "", err
+	}
+	if aws.BoolValue(optOut.IsOptedOut) {
+		return "", errors.Wrap(ErrPhoneOptedOut, phoneNumber)
+	}
+
+	if segments := SMSSegments(text); segments > 1 {
+		log.Printf("SMS is sent in %d parts", segments)
+	}
+
+	smsType := SMSTypeTransactional
+	attributes := map[string]*sns.MessageAttributeValue{}
+	if options != nil {
+		if options.Type != "" {
+			smsType = options.Type
+		}
+		if options.SenderID != "" {
+			attributes["AWS.SNS.SMS.SenderID"] = SNSStringAttribute(options.SenderID)
+		}
+	}
+	attributes["AWS.SNS.SMS.SMSType"] = SNSStringAttribute(smsType)
+
+	resp, err := client.publish(&sns.PublishInput{
+		Message:           aws.String(text),
+		PhoneNumber:       aws.String(phoneNumber),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		log.Println("Failed to send SMS", err)
+		return "", err
+	}
+
+	log.Println("SMS sent, messageId: " + aws.StringValue(resp.MessageId))
+	return aws.StringValue(resp.MessageId), nil
+}
+
+// SendCheckInReminder sends the CheckInReminderTemplate to the phone number of the user
+func (client *Client) SendCheckInReminder(user *dynamo.UserModel, reservation *dynamo.ReservationModel, apartmentName string, options *SMSOptions) (string, error) {
+	text := RenderSMS(CheckInReminderTemplate, map[string]string{
+		"name":          user.FullName,
+		"apartment":     apartmentName,
+		"fromDate":      reservation.FromDate,
+		"toDate":        reservation.ToDate,
+		"reservationId": reservation.ReservationID,
+	})
+
+	return client.SendSMS(user.Phone, text, options)
+}
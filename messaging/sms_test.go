@@ -0,0 +1,86 @@
+package messaging
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+
+	"github.com/sylank/lavender-commons-go/dynamo"
+)
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		phone       string
+		countryCode string
+		expected    string
+		err         error
+	}{
+		{desc: "E.164 number", phone: "+36 30 123 4567", countryCode: "36", expected: "+36301234567"},
+		{desc: "International prefix", phone: "0036-30-123-4567", countryCode: "36", expected: "+36301234567"},
+		{desc: "Hungarian national format", phone: "06 (30) 123 4567", countryCode: "36", expected: "+36301234567"},
+		{desc: "German national format", phone: "0151 2345 6789", countryCode: "49", expected: "+4915123456789"},
+		{desc: "Cleared user data", phone: "#CLEARED#", countryCode: "36", err: ErrInvalidPhoneNumber},
+		{desc: "Too short", phone: "+3612", countryCode: "36", err: ErrInvalidPhoneNumber},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			normalized, err := NormalizePhoneNumber(tC.phone, tC.countryCode)
+			if errors.Cause(err) != tC.err {
+				t.Fatalf("expected error %v, got %v", tC.err, err)
+			}
+
+			if normalized != tC.expected {
+				t.Errorf("expected %s, got %s", tC.expected, normalized)
+			}
+		})
+	}
+}
+
+func TestSendCheckInReminder(t *testing.T) {
+	topic := &memoryTopic{optedOut: map[string]bool{"+36209999999": true}}
+	client := NewClientWithAPIs(nil, topic)
+
+	reservation := &dynamo.ReservationModel{ReservationID: "reservation-1", FromDate: "2026-07-10"}
+	_, err := client.SendCheckInReminder(&dynamo.UserModel{FullName: "Kovács Anna", Phone: "06 30 123 4567"}, reservation, "Lavender", &SMSOptions{SenderID: "Lavender"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := topic.published[0]
+	if aws.StringValue(input.PhoneNumber) != "+36301234567" {
+		t.Errorf("unexpected phone number: %s", aws.StringValue(input.PhoneNumber))
+	}
+	if aws.StringValue(input.Message) != "Dear Kovács Anna, we are expecting you at Lavender on 2026-07-10. Reservation: reservation-1" {
+		t.Errorf("unexpected text: %s", aws.StringValue(input.Message))
+	}
+	if aws.StringValue(input.MessageAttributes["AWS.SNS.SMS.SMSType"].StringValue) != SMSTypeTransactional ||
+		aws.StringValue(input.MessageAttributes["AWS.SNS.SMS.SenderID"].StringValue) != "Lavender" {
+		t.Errorf("unexpected SMS attributes: %v", input.MessageAttributes)
+	}
+
+	_, err = client.SendSMS("+36 20 999 9999", "text", nil)
+	if errors.Cause(err) != ErrPhoneOptedOut {
+		t.Errorf("expected opt-out error, got %v", err)
+	}
+	if len(topic.published) != 1 {
+		t.Error("opted out number should not receive SMS")
+	}
+}
+
+func TestSMSSegments(t *testing.T) {
+	long := make([]byte, 161)
+	for i := range long {
+		long[i] = 'a'
+	}
+
+	if SMSSegments("short") != 1 || SMSSegments(string(long)) != 2 {
+		t.Error("unexpected GSM segment count")
+	}
+
+	if SMSSegments(strings.Repeat("Árvíztűrő tükörfúrógép ", 4)) != 2 {
+		t.Error("unicode text should use 70 character segments")
+	}
+}
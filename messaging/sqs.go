@@ -4,40 +4,16 @@ import (
 	"log"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 )
 
 // SendTransactionalEmail ...
 func SendTransactionalEmail(message string, queueName string) error {
-	sess := session.Must(session.NewSessionWithOptions(session.Options{
-		SharedConfigState: session.SharedConfigEnable,
-	}))
-
-	svc := sqs.New(sess)
-
-	qURL, err := getQueueURL(queueName, svc)
-	if err != nil {
-		log.Println("Failed to fetch queue URL")
-		return err
-	}
-
-	result, err := svc.SendMessage(&sqs.SendMessageInput{
-		DelaySeconds: aws.Int64(10),
-		MessageBody: aws.String(message),
-		QueueUrl:    &qURL,
-	})
-
-	if err != nil {
-		log.Println("Failed to send message", err)
-		return err
-	}
-
-	log.Println("Success", *result.MessageId)
-	return nil
+	return GetDefaultClient().SendTransactionalEmail(message, queueName)
 }
 
-func getQueueURL(ququeName string, svc *sqs.SQS) (string, error) {
+func getQueueURL(ququeName string, svc sqsiface.SQSAPI) (string, error) {
 	result, err := svc.GetQueueUrl(&sqs.GetQueueUrlInput{
 		QueueName: aws.String(ququeName),
 	})
@@ -0,0 +1,128 @@
+package messaging
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/pkg/errors"
+
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+// Message attribute names used by the SNS subscription filter policies
+const (
+	EventTypeAttribute     = "eventType"
+	ApartmentCodeAttribute = "apartmentCode"
+	EnvironmentAttribute   = "environment"
+)
+
+// ErrUnknownTopic is returned when the topic is not configured in the messaging properties
+var ErrUnknownTopic = errors.New("unknown topic")
+
+// PublishOptions ...
+type PublishOptions struct {
+	Subject    string
+	Attributes map[string]*sns.MessageAttributeValue
+}
+
+// ProtocolMessages holds a message per subscription protocol, Default is used by every protocol without its own message
+type ProtocolMessages struct {
+	Default string `json:"default"`
+	Email   string `json:"email,omitempty"`
+	SMS     string `json:"sms,omitempty"`
+}
+
+// SNSStringAttribute ...
+func SNSStringAttribute(value string) *sns.MessageAttributeValue {
+	return &sns.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(value),
+	}
+}
+
+// FilterAttributes returns the message attributes matched by the subscription filter policies,
+// the environment is always set, empty values are left out
+func FilterAttributes(eventType string, apartmentCode string) map[string]*sns.MessageAttributeValue {
+	attributes := map[string]*sns.MessageAttributeValue{}
+	if eventType != "" {
+		attributes[EventTypeAttribute] = SNSStringAttribute(eventType)
+	}
+	if apartmentCode != "" {
+		attributes[ApartmentCodeAttribute] = SNSStringAttribute(apartmentCode)
+	}
+	if environment := props.GetEnvironmentName(); environment != "" {
+		attributes[EnvironmentAttribute] = SNSStringAttribute(environment)
+	}
+
+	return attributes
+}
+
+// SetMessagingProperties sets the properties used for resolving topic names
+func (client *Client) SetMessagingProperties(messagingProperties *props.MessagingProperties) {
+	client.messagingProperties = messagingProperties
+}
+
+// TopicArn resolves a topic name from the messaging properties
+func (client *Client) TopicArn(topicName string) (string, error) {
+	if client.messagingProperties == nil {
+		return "", errors.Wrap(ErrUnknownTopic, "messaging properties are not set")
+	}
+
+	topicArn := client.messagingProperties.GetTopicArn(topicName)
+	if topicArn == "" {
+		return "", errors.Wrap(ErrUnknownTopic, topicName)
+	}
+
+	return topicArn, nil
+}
+
+// PublishToTopic publishes the message to a named topic and returns the message ID
+func (client *Client) PublishToTopic(topicName string, message string, options *PublishOptions) (string, error) {
+	return client.publishToTopic(topicName, message, nil, options)
+}
+
+// PublishStructured publishes a different message per subscription protocol, e.g. a long text for
+// email and a short one for SMS subscribers
+func (client *Client) PublishStructured(topicName string, messages *ProtocolMessages, options *PublishOptions) (string, error) {
+	if messages.Default == "" {
+		return "", errors.New("default message is required for structured messages")
+	}
+
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return "", err
+	}
+
+	return client.publishToTopic(topicName, string(data), aws.String("json"), options)
+}
+
+func (client *Client) publishToTopic(topicName string, message string, messageStructure *string, options *PublishOptions) (string, error) {
+	topicArn, err := client.TopicArn(topicName)
+	if err != nil {
+		log.Println("Failed to resolve topic", err)
+		return "", err
+	}
+
+	params := &sns.PublishInput{
+		Message:          aws.String(message),
+		MessageStructure: messageStructure,
+		TopicArn:         aws.String(topicArn),
+	}
+	if options != nil {
+		params.Subject = optionalString(options.Subject)
+		if len(options.Attributes) > 0 {
+			params.MessageAttributes = options.Attributes
+		}
+	}
+
+	resp, err := client.publish(params)
+	if err != nil {
+		log.Println("Failed to publish message to topic: "+topicName, err)
+		return "", err
+	}
+
+	log.Println("Message published to topic: " + topicName + " messageId: " + aws.StringValue(resp.MessageId))
+	return aws.StringValue(resp.MessageId), nil
+}
@@ -0,0 +1,107 @@
+package messaging
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/pkg/errors"
+
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+// memoryTopic is a fake of the SNS API recording the published messages
+type memoryTopic struct {
+	snsiface.SNSAPI
+
+	published []*sns.PublishInput
+	optedOut  map[string]bool
+}
+
+func (topic *memoryTopic) Publish(input *sns.PublishInput) (*sns.PublishOutput, error) {
+	topic.published = append(topic.published, input)
+
+	return &sns.PublishOutput{MessageId: aws.String("message-id")}, nil
+}
+
+func (topic *memoryTopic) CheckIfPhoneNumberIsOptedOut(input *sns.CheckIfPhoneNumberIsOptedOutInput) (*sns.CheckIfPhoneNumberIsOptedOutOutput, error) {
+	return &sns.CheckIfPhoneNumberIsOptedOutOutput{IsOptedOut: aws.Bool(topic.optedOut[aws.StringValue(input.PhoneNumber)])}, nil
+}
+
+func newTopicClient() (*Client, *memoryTopic) {
+	topic := &memoryTopic{}
+	client := NewClientWithAPIs(nil, topic)
+	client.SetMessagingProperties(&props.MessagingProperties{
+		Topics: map[string]props.TopicInfo{
+			"reservationEvents": {TopicArn: "arn:aws:sns:eu-central-1:123456789012:reservation-events"},
+		},
+	})
+
+	return client, topic
+}
+
+func TestPublishToTopicWithFilterAttributes(t *testing.T) {
+	os.Setenv("environment_name", "test")
+	defer os.Unsetenv("environment_name")
+
+	client, topic := newTopicClient()
+	_, err := client.PublishToTopic("reservationEvents", "message", &PublishOptions{
+		Subject:    "Reservation created",
+		Attributes: FilterAttributes("ReservationCreated", "lavender"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := topic.published[0]
+	if aws.StringValue(input.TopicArn) != "arn:aws:sns:eu-central-1:123456789012:reservation-events" {
+		t.Errorf("unexpected topic: %s", aws.StringValue(input.TopicArn))
+	}
+
+	expected := map[string]string{
+		EventTypeAttribute:     "ReservationCreated",
+		ApartmentCodeAttribute: "lavender",
+		EnvironmentAttribute:   "test",
+	}
+	for name, value := range expected {
+		if aws.StringValue(input.MessageAttributes[name].StringValue) != value {
+			t.Errorf("expected attribute %s=%s, got %v", name, value, input.MessageAttributes[name])
+		}
+	}
+
+	_, err = client.PublishToTopic("unknown", "message", nil)
+	if errors.Cause(err) != ErrUnknownTopic {
+		t.Errorf("expected unknown topic error, got %v", err)
+	}
+}
+
+func TestPublishStructured(t *testing.T) {
+	client, topic := newTopicClient()
+	_, err := client.PublishStructured("reservationEvents", &ProtocolMessages{
+		Default: "Your reservation is confirmed",
+		SMS:     "Reservation confirmed",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := topic.published[0]
+	if aws.StringValue(input.MessageStructure) != "json" {
+		t.Error("message structure should be json")
+	}
+
+	var messages map[string]string
+	if err := json.Unmarshal([]byte(aws.StringValue(input.Message)), &messages); err != nil {
+		t.Fatal(err)
+	}
+
+	if messages["default"] != "Your reservation is confirmed" || messages["sms"] != "Reservation confirmed" {
+		t.Errorf("unexpected structured message: %v", messages)
+	}
+	if _, ok := messages["email"]; ok {
+		t.Error("empty protocol messages should be left out")
+	}
+}
@@ -0,0 +1,301 @@
+package money
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultCurrency is used for amounts stored before the currency was recorded
+const DefaultCurrency = "HUF"
+
+// DefaultLocale ...
+const DefaultLocale = "hu-HU"
+
+// ErrCurrencyMismatch is returned when two amounts with different currencies are combined
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money is an amount in the minor unit of an ISO 4217 currency
+type Money struct {
+	Amount   int64  `json:"Amount"`
+	Currency string `json:"Currency"`
+}
+
+type currencyInfo struct {
+	symbol    string
+	minorUnit int
+}
+
+var currencies = map[string]currencyInfo{
+	"HUF": {symbol: "Ft", minorUnit: 2},
+	"EUR": {symbol: "€", minorUnit: 2},
+	"USD": {symbol: "$", minorUnit: 2},
+	"GBP": {symbol: "£", minorUnit: 2},
+	"CHF": {symbol: "CHF", minorUnit: 2},
+	"CZK": {symbol: "Kč", minorUnit: 2},
+	"PLN": {symbol: "zł", minorUnit: 2},
+	"RON": {symbol: "lei", minorUnit: 2},
+	"JPY": {symbol: "¥", minorUnit: 0},
+}
+
+type localeFormat struct {
+	groupSeparator   string
+	decimalSeparator string
+	symbolFirst      bool
+	symbolSpace      bool
+	hideMinorUnits   map[string]bool
+}
+
+var locales = map[string]localeFormat{
+	"hu-HU": {groupSeparator: " ", decimalSeparator: ",", symbolFirst: false, symbolSpace: true, hideMinorUnits: map[string]bool{"HUF": true}},
+	"en-US": {groupSeparator: ",", decimalSeparator: ".", symbolFirst: true, symbolSpace: false},
+	"en-GB": {groupSeparator: ",", decimalSeparator: ".", symbolFirst: true, symbolSpace: false},
+	"de-DE": {groupSeparator: ".", decimalSeparator: ",", symbolFirst: false, symbolSpace: true},
+	"de-AT": {groupSeparator: " ", decimalSeparator: ",", symbolFirst: true, symbolSpace: true},
+}
+
+// New ...
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// FromMajor creates an amount from whole currency units, e.g. FromMajor(25000, "HUF")
+func FromMajor(amount int64, currency string) Money {
+	currency = strings.ToUpper(currency)
+
+	return Money{Amount: amount * pow10(MinorUnit(currency)), Currency: currency}
+}
+
+// IsSupportedCurrency ...
+func IsSupportedCurrency(currency string) bool {
+	_, ok := currencies[strings.ToUpper(currency)]
+
+	return ok
+}
+
+// MinorUnit returns the number of decimal digits of the currency
+func MinorUnit(currency string) int {
+	info, ok := currencies[strings.ToUpper(currency)]
+	if !ok {
+		return 2
+	}
+
+	return info.minorUnit
+}
+
+// IsZero ...
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative ...
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add ...
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Sub ...
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}, nil
+}
+
+// Multiply ...
+func (m Money) Multiply(factor int64) Money {
+	return Money{Amount: m.Amount * factor, Currency: m.Currency}
+}
+
+// Percent returns the given percentage of the amount, rounded half away from zero
+func (m Money) Percent(percent int64) Money {
+	product := m.Amount * percent
+	amount := product / 100
+	remainder := product % 100
+	if remainder >= 50 {
+		amount++
+	} else if remainder <= -50 {
+		amount--
+	}
+
+	return Money{Amount: amount, Currency: m.Currency}
+}
+
+// BalanceDue returns the cost minus the already paid deposit
+func BalanceDue(cost Money, deposit Money) (Money, error) {
+	return cost.Sub(deposit)
+}
+
+// MinorUnitsString returns the amount as a plain integer string, as stored in DynamoDB
+func (m Money) MinorUnitsString() string {
+	return strconv.FormatInt(m.Amount, 10)
+}
+
+// ParseMinorUnits parses an integer string of minor units, an empty currency falls back to DefaultCurrency
+func ParseMinorUnits(amount string, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	value, err := strconv.ParseInt(strings.TrimSpace(amount), 10, 64)
+	if err != nil {
+		return Money{}, errors.Wrapf(err, "invalid amount: %s", amount)
+	}
+
+	return New(value, currency), nil
+}
+
+// MajorUnitsString returns the amount in whole currency units, the minor units are added only when not zero,
+// e.g. "25000" or "125.50"
+func (m Money) MajorUnitsString() string {
+	return m.formatNumber(".", "", true)
+}
+
+// ParseMajorUnits parses a decimal string of whole currency units, e.g. "125.5", an empty currency falls back
+// to DefaultCurrency
+func ParseMajorUnits(amount string, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	currency = strings.ToUpper(currency)
+
+	value := strings.TrimSpace(amount)
+	sign := int64(1)
+	if strings.HasPrefix(value, "-") {
+		sign = -1
+		value = value[1:]
+	}
+
+	major, minor := value, ""
+	if index := strings.Index(value, "."); index >= 0 {
+		major, minor = value[:index], value[index+1:]
+	}
+
+	digits := MinorUnit(currency)
+	if major == "" || len(minor) > digits || strings.HasPrefix(major, "+") {
+		return Money{}, errors.Errorf("invalid amount: %s", amount)
+	}
+
+	majorValue, err := strconv.ParseInt(major, 10, 64)
+	if err != nil {
+		return Money{}, errors.Wrapf(err, "invalid amount: %s", amount)
+	}
+
+	minorValue := int64(0)
+	if minor != "" {
+		minorValue, err = strconv.ParseInt(minor, 10, 64)
+		if err != nil || strings.HasPrefix(minor, "-") || strings.HasPrefix(minor, "+") {
+			return Money{}, errors.Errorf("invalid amount: %s", amount)
+		}
+		minorValue *= pow10(digits - len(minor))
+	}
+
+	return Money{Amount: sign * (majorValue*pow10(digits) + minorValue), Currency: currency}, nil
+}
+
+// String ...
+func (m Money) String() string {
+	return fmt.Sprintf("%s %s", m.formatNumber(".", "", false), m.Currency)
+}
+
+// Format formats the amount for the given locale, e.g. "25 000 Ft" for hu-HU or "€250.00" for en-US.
+// Unknown locales fall back to DefaultLocale.
+func (m Money) Format(locale string) string {
+	format, ok := locales[locale]
+	if !ok {
+		format = locales[DefaultLocale]
+	}
+
+	symbol := m.Currency
+	if info, ok := currencies[m.Currency]; ok {
+		symbol = info.symbol
+	}
+
+	number := m.formatNumber(format.decimalSeparator, format.groupSeparator, format.hideMinorUnits[m.Currency])
+
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign = "-"
+		number = number[1:]
+	}
+
+	space := ""
+	if format.symbolSpace {
+		space = " "
+	}
+
+	if format.symbolFirst {
+		return sign + symbol + space + number
+	}
+
+	return sign + number + space + symbol
+}
+
+func (m Money) formatNumber(decimalSeparator string, groupSeparator string, hideMinorUnits bool) string {
+	digits := MinorUnit(m.Currency)
+	divisor := pow10(digits)
+
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	major := groupDigits(strconv.FormatInt(amount/divisor, 10), groupSeparator)
+	minor := amount % divisor
+
+	if digits == 0 || (hideMinorUnits && minor == 0) {
+		return sign + major
+	}
+
+	return fmt.Sprintf("%s%s%s%0*d", sign, major, decimalSeparator, digits, minor)
+}
+
+func (m Money) checkCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return errors.Wrapf(ErrCurrencyMismatch, "%s and %s", m.Currency, other.Currency)
+	}
+
+	return nil
+}
+
+func groupDigits(digits string, separator string) string {
+	if separator == "" || len(digits) <= 3 {
+		return digits
+	}
+
+	var builder strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		builder.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if builder.Len() > 0 {
+			builder.WriteString(separator)
+		}
+		builder.WriteString(digits[i : i+3])
+	}
+
+	return builder.String()
+}
+
+func pow10(n int) int64 {
+	result := int64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+
+	return result
+}
@@ -0,0 +1,152 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		value    Money
+		locale   string
+		expected string
+	}{
+		{
+			desc:     "Hungarian forint without minor units",
+			value:    FromMajor(125000, "HUF"),
+			locale:   "hu-HU",
+			expected: "125 000 Ft",
+		},
+		{
+			desc:     "Hungarian forint with minor units",
+			value:    New(12345050, "HUF"),
+			locale:   "hu-HU",
+			expected: "123 450,50 Ft",
+		},
+		{
+			desc:     "Euro in en-US locale",
+			value:    New(123456, "EUR"),
+			locale:   "en-US",
+			expected: "€1,234.56",
+		},
+		{
+			desc:     "Euro in de-DE locale",
+			value:    New(123456, "EUR"),
+			locale:   "de-DE",
+			expected: "1.234,56 €",
+		},
+		{
+			desc:     "Negative amount",
+			value:    New(-5000, "USD"),
+			locale:   "en-US",
+			expected: "-$50.00",
+		},
+		{
+			desc:     "Unknown locale falls back to the default",
+			value:    FromMajor(1000, "HUF"),
+			locale:   "xx-XX",
+			expected: "1 000 Ft",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			formatted := tC.value.Format(tC.locale)
+			if formatted != tC.expected {
+				t.Errorf("expected %q, got %q", tC.expected, formatted)
+			}
+		})
+	}
+}
+
+func TestBalanceDue(t *testing.T) {
+	balanceDue, err := BalanceDue(FromMajor(60000, "HUF"), FromMajor(18000, "HUF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if balanceDue != FromMajor(42000, "HUF") {
+		t.Errorf("unexpected balance due: %s", balanceDue)
+	}
+
+	_, err = BalanceDue(FromMajor(100, "EUR"), FromMajor(10, "HUF"))
+	if errors.Cause(err) != ErrCurrencyMismatch {
+		t.Errorf("expected currency mismatch, got %v", err)
+	}
+}
+
+func TestPercent(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		value    Money
+		percent  int64
+		expected int64
+	}{
+		{desc: "Exact percentage", value: New(10000, "HUF"), percent: 30, expected: 3000},
+		{desc: "Rounds half up", value: New(105, "HUF"), percent: 50, expected: 53},
+		{desc: "Rounds down", value: New(101, "HUF"), percent: 30, expected: 30},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			result := tC.value.Percent(tC.percent)
+			if result.Amount != tC.expected {
+				t.Errorf("expected %d, got %d", tC.expected, result.Amount)
+			}
+		})
+	}
+}
+
+func TestParseMinorUnits(t *testing.T) {
+	value, err := ParseMinorUnits("2500000", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value != New(2500000, DefaultCurrency) {
+		t.Errorf("unexpected value: %s", value)
+	}
+
+	if _, err := ParseMinorUnits("abc", "EUR"); err == nil {
+		t.Error("expected error for invalid amount")
+	}
+}
+
+func TestMajorUnits(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		amount   string
+		currency string
+		expected Money
+		str      string
+	}{
+		{desc: "whole forints", amount: "25000", currency: "", expected: FromMajor(25000, "HUF"), str: "25000"},
+		{desc: "cents", amount: "125.5", currency: "EUR", expected: New(12550, "EUR"), str: "125.50"},
+		{desc: "negative", amount: "-0.05", currency: "EUR", expected: New(-5, "EUR"), str: "-0.05"},
+		{desc: "no minor unit", amount: "300", currency: "JPY", expected: New(300, "JPY"), str: "300"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			value, err := ParseMajorUnits(tC.amount, tC.currency)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != tC.expected {
+				t.Errorf("unexpected value: %s", value)
+			}
+			if value.MajorUnitsString() != tC.str {
+				t.Errorf("unexpected string: %s", value.MajorUnitsString())
+			}
+		})
+	}
+
+	for _, amount := range []string{"", "abc", "1.234", "1.-5", "300.5"} {
+		currency := "EUR"
+		if amount == "300.5" {
+			currency = "JPY"
+		}
+		if _, err := ParseMajorUnits(amount, currency); err == nil {
+			t.Errorf("expected error for invalid amount: %q", amount)
+		}
+	}
+}
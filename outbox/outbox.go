@@ -0,0 +1,190 @@
+package outbox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
+	"github.com/sylank/lavender-commons-go/dynamo"
+	"github.com/sylank/lavender-commons-go/events"
+	"github.com/sylank/lavender-commons-go/retry"
+)
+
+// Record statuses
+const (
+	StatusPending = "PENDING"
+	StatusSent    = "SENT"
+)
+
+// Record is an event waiting to be published, stored in the outbox table
+type Record struct {
+	OutboxID      string `dynamodbav:"OutboxId"`
+	EventType     string `dynamodbav:"EventType"`
+	AggregateID   string `dynamodbav:"AggregateId"`
+	ApartmentCode string `dynamodbav:"ApartmentCode"`
+	Envelope      string `dynamodbav:"Envelope"`
+	Status        string `dynamodbav:"Status"`
+	CreatedAt     string `dynamodbav:"CreatedAt"`
+	SentAt        string `dynamodbav:"SentAt,omitempty"`
+}
+
+// NewRecord wraps the event into an envelope, the envelope ID is used as the outbox ID
+func NewRecord(event events.Event) (*Record, error) {
+	envelope, err := events.NewEnvelope(event)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := envelope.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	apartmentCode := ""
+	if apartmentEvent, ok := event.(events.ApartmentEvent); ok {
+		apartmentCode = apartmentEvent.GetApartmentCode()
+	}
+
+	return &Record{
+		OutboxID:      envelope.ID,
+		EventType:     envelope.Type,
+		AggregateID:   envelope.AggregateID,
+		ApartmentCode: apartmentCode,
+		Envelope:      body,
+		Status:        StatusPending,
+		CreatedAt:     envelope.OccurredAt.Format(time.RFC3339Nano),
+	}, nil
+}
+
+// PutItem returns the insert of the record for a TransactWriteItems call
+func (record *Record) PutItem(table string) (*dynamodb.TransactWriteItem, error) {
+	av, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			Item:                av,
+			TableName:           aws.String(table),
+			ConditionExpression: aws.String("attribute_not_exists(OutboxId)"),
+		},
+	}, nil
+}
+
+// Writer writes reservation changes together with their events in one transaction
+type Writer struct {
+	// RetryPolicy is applied to throttled and failed DynamoDB calls
+	RetryPolicy retry.Policy
+
+	svc         dynamodbiface.DynamoDBAPI
+	outboxTable string
+}
+
+// NewWriter ...
+func NewWriter(svc dynamodbiface.DynamoDBAPI, outboxTable string) *Writer {
+	return &Writer{RetryPolicy: retry.AWSPolicy(), svc: svc, outboxTable: outboxTable}
+}
+
+// Write executes the items and inserts an outbox record for each event atomically. The request token is derived
+// from the outbox IDs, so a retried transaction which was already committed succeeds instead of failing the
+// condition of the outbox records.
+func (writer *Writer) Write(items []*dynamodb.TransactWriteItem, evts ...events.Event) error {
+	var outboxIDs []string
+	for _, event := range evts {
+		record, err := NewRecord(event)
+		if err != nil {
+			return err
+		}
+
+		item, err := record.PutItem(writer.outboxTable)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+		outboxIDs = append(outboxIDs, record.OutboxID)
+	}
+
+	input := &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	}
+	if len(outboxIDs) > 0 {
+		input.ClientRequestToken = aws.String(requestToken(outboxIDs))
+	}
+
+	_, err := writer.transactWriteItems(input)
+	if err != nil {
+		log.Println("Got error calling TransactWriteItems", err)
+		return err
+	}
+
+	return nil
+}
+
+// requestToken returns the ClientRequestToken of the transaction, DynamoDB accepts at most 36 characters
+func requestToken(outboxIDs []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(outboxIDs, ",")))
+
+	return hex.EncodeToString(sum[:])[:36]
+}
+
+// InsertReservation stores the reservation and a ReservationCreated event
+func (writer *Writer) InsertReservation(reservationModel *dynamo.ReservationModel, table string) error {
+	item, err := dynamo.ReservationPutItem(reservationModel, table)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Write([]*dynamodb.TransactWriteItem{item}, events.NewReservationCreated(reservationModel))
+	if err != nil {
+		return err
+	}
+
+	log.Println("Item inserted with reservationId: " + reservationModel.ReservationID)
+	return nil
+}
+
+// CancelReservation marks the reservation deleted, stores the deletion data and a ReservationCancelled event
+func (writer *Writer) CancelReservation(reservationModel *dynamo.ReservationModel, deletionModel *dynamo.DeletionInsertModel, reservationTable string, deletionTable string) error {
+	items := []*dynamodb.TransactWriteItem{
+		dynamo.DeletedReservationStatusUpdateItem(reservationModel.ReservationID, reservationTable),
+	}
+
+	reason := ""
+	if deletionModel != nil {
+		item, err := dynamo.DeletionPutItem(deletionModel, deletionTable)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+		reason = deletionModel.Message
+	}
+
+	err := writer.Write(items, events.NewReservationCancelled(reservationModel, reason))
+	if err != nil {
+		return err
+	}
+
+	log.Println("Reservation cancelled with reservationId: " + reservationModel.ReservationID)
+	return nil
+}
+
+// UpdateReservationDates moves the reservation to the given dates and stores a ReservationDatesChanged event
+func (writer *Writer) UpdateReservationDates(reservationModel *dynamo.ReservationModel, fromDate string, toDate string, table string) error {
+	item := dynamo.ReservationDatesUpdateItem(reservationModel.ReservationID, fromDate, toDate, table)
+
+	err := writer.Write([]*dynamodb.TransactWriteItem{item}, events.NewReservationDatesChanged(reservationModel, fromDate, toDate))
+	if err != nil {
+		return err
+	}
+
+	log.Println("Dates updated with reservationId: " + reservationModel.ReservationID)
+	return nil
+}
@@ -0,0 +1,244 @@
+package outbox
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+
+	"github.com/sylank/lavender-commons-go/dynamo"
+	"github.com/sylank/lavender-commons-go/events"
+	"github.com/sylank/lavender-commons-go/money"
+	"github.com/sylank/lavender-commons-go/retry"
+)
+
+const outboxTable = "lavender-test-outbox"
+
+type memoryTable struct {
+	dynamodbiface.DynamoDBAPI
+
+	transactions [][]*dynamodb.TransactWriteItem
+	outbox       map[string]map[string]*dynamodb.AttributeValue
+	// throttled makes the given number of transactions fail with a throttling error
+	throttled     int
+	requestTokens []string
+}
+
+func (table *memoryTable) TransactWriteItems(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	table.requestTokens = append(table.requestTokens, aws.StringValue(input.ClientRequestToken))
+	if table.throttled > 0 {
+		table.throttled--
+		return nil, awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "slow down", nil)
+	}
+
+	table.transactions = append(table.transactions, input.TransactItems)
+	for _, item := range input.TransactItems {
+		if item.Put != nil && aws.StringValue(item.Put.TableName) == outboxTable {
+			table.outbox[aws.StringValue(item.Put.Item["OutboxId"].S)] = item.Put.Item
+		}
+	}
+
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (table *memoryTable) ScanPages(input *dynamodb.ScanInput, fn func(*dynamodb.ScanOutput, bool) bool) error {
+	page := &dynamodb.ScanOutput{}
+	for _, item := range table.outbox {
+		if aws.StringValue(item["Status"].S) == StatusPending {
+			page.Items = append(page.Items, item)
+		}
+	}
+	fn(page, true)
+
+	return nil
+}
+
+func (table *memoryTable) GetItem(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: table.outbox[aws.StringValue(input.Key["OutboxId"].S)]}, nil
+}
+
+func (table *memoryTable) UpdateItem(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	item := table.outbox[aws.StringValue(input.Key["OutboxId"].S)]
+	if aws.StringValue(item["Status"].S) != StatusPending {
+		return nil, errors.New("conditional check failed")
+	}
+	item["Status"] = input.ExpressionAttributeValues[":sent"]
+
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+type recordingPublisher struct {
+	envelopes []*events.Envelope
+	fail      bool
+}
+
+func (publisher *recordingPublisher) PublishEnvelope(envelope *events.Envelope, apartmentCode string) error {
+	if publisher.fail {
+		return errors.New("publish failed")
+	}
+	publisher.envelopes = append(publisher.envelopes, envelope)
+
+	return nil
+}
+
+func testReservation() *dynamo.ReservationModel {
+	return &dynamo.ReservationModel{
+		ReservationID:    "reservation-1",
+		UserID:           "user-1",
+		ApartmentCode:    "lavender",
+		FromDate:         "2026-07-10",
+		ToDate:           "2026-07-15",
+		CostValue:        money.FromMajor(125000, "HUF"),
+		DepositCostValue: money.FromMajor(37500, "HUF"),
+	}
+}
+
+func TestWriterStoresEventInSameTransaction(t *testing.T) {
+	table := &memoryTable{outbox: map[string]map[string]*dynamodb.AttributeValue{}}
+	writer := NewWriter(table, outboxTable)
+
+	err := writer.InsertReservation(testReservation(), "lavender-test-reservations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(table.transactions) != 1 || len(table.transactions[0]) != 2 {
+		t.Fatalf("expected one transaction with two items: %v", table.transactions)
+	}
+
+	if len(table.outbox) != 1 {
+		t.Errorf("expected one outbox record, got %d", len(table.outbox))
+	}
+}
+
+func TestWriterRetriesThrottledTransaction(t *testing.T) {
+	table := &memoryTable{outbox: map[string]map[string]*dynamodb.AttributeValue{}, throttled: 2}
+	writer := NewWriter(table, outboxTable)
+	writer.RetryPolicy = retry.Policy{MaxAttempts: 3, Retryable: retry.IsAWSRetryable}
+
+	err := writer.InsertReservation(testReservation(), "lavender-test-reservations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(table.transactions) != 1 {
+		t.Errorf("expected the transaction written after the retries, got %d", len(table.transactions))
+	}
+	if len(table.requestTokens) != 3 || table.requestTokens[0] == "" || table.requestTokens[0] != table.requestTokens[2] {
+		t.Errorf("retries should send the same request token: %v", table.requestTokens)
+	}
+}
+
+func TestRelayPublishesPendingRecords(t *testing.T) {
+	table := &memoryTable{outbox: map[string]map[string]*dynamodb.AttributeValue{}}
+	writer := NewWriter(table, outboxTable)
+	if err := writer.CancelReservation(testReservation(), &dynamo.DeletionInsertModel{
+		UserID:        "user-1",
+		ReservationID: "reservation-1",
+		Type:          "guest",
+		Message:       "Plans changed",
+	}, "lavender-test-reservations", "lavender-test-deletions"); err != nil {
+		t.Fatal(err)
+	}
+
+	publisher := &recordingPublisher{fail: true}
+	relay := NewRelay(table, outboxTable, publisher)
+	if _, err := relay.PollPending(); err == nil {
+		t.Error("publish failure should be returned")
+	}
+
+	publisher.fail = false
+	published, err := relay.PollPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if published != 1 {
+		t.Errorf("expected one published record, got %d", published)
+	}
+
+	event, err := publisher.envelopes[0].Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled, ok := event.(*events.ReservationCancelled); !ok || cancelled.Reason != "Plans changed" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+
+	if published, _ := relay.PollPending(); published != 0 {
+		t.Error("sent records should not be published again")
+	}
+}
+
+func TestRelayProcessesStreamEvent(t *testing.T) {
+	table := &memoryTable{outbox: map[string]map[string]*dynamodb.AttributeValue{}}
+	record, err := NewRecord(events.NewReservationCreated(testReservation()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	image, _ := dynamodbattribute.MarshalMap(record)
+	table.outbox[record.OutboxID] = image
+
+	// The Lambda event uses the same attribute value JSON format as the DynamoDB API
+	imageJSON, _ := json.Marshal(image)
+	body := `{"Records":[{"eventID":"1","eventName":"INSERT","dynamodb":{"NewImage":` + string(imageJSON) + `}},` +
+		`{"eventID":"2","eventName":"MODIFY","dynamodb":{"NewImage":` + string(imageJSON) + `}}]}`
+
+	streamEvent := &StreamEvent{}
+	if err := json.Unmarshal([]byte(body), streamEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	publisher := &recordingPublisher{}
+	relay := NewRelay(table, outboxTable, publisher)
+	err = relay.ProcessStreamEvent(streamEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A retried stream batch carries the same pending image
+	err = relay.ProcessStreamEvent(streamEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(publisher.envelopes) != 1 || publisher.envelopes[0].ID != record.OutboxID {
+		t.Errorf("only the inserted record should be published: %v", publisher.envelopes)
+	}
+
+	if aws.StringValue(table.outbox[record.OutboxID]["Status"].S) != StatusSent {
+		t.Error("record should be marked sent")
+	}
+}
+
+func TestWriterUpdatesReservationDates(t *testing.T) {
+	table := &memoryTable{outbox: map[string]map[string]*dynamodb.AttributeValue{}}
+	writer := NewWriter(table, outboxTable)
+
+	err := writer.UpdateReservationDates(testReservation(), "2026-07-11", "2026-07-16", "lavender-test-reservations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(table.transactions) != 1 || len(table.transactions[0]) != 2 || table.transactions[0][0].Update == nil {
+		t.Fatalf("expected the date update and the event in one transaction: %v", table.transactions)
+	}
+
+	publisher := &recordingPublisher{}
+	if _, err := NewRelay(table, outboxTable, publisher).PollPending(); err != nil {
+		t.Fatal(err)
+	}
+	event, err := publisher.envelopes[0].Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, ok := event.(*events.ReservationDatesChanged)
+	if !ok || changed.FromDate != "2026-07-11" || changed.PreviousFromDate != "2026-07-10" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
@@ -0,0 +1,251 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	expression "github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/pkg/errors"
+
+	"github.com/sylank/lavender-commons-go/events"
+	"github.com/sylank/lavender-commons-go/retry"
+)
+
+// EnvelopePublisher is implemented by events.SNSPublisher and events.SQSPublisher
+type EnvelopePublisher interface {
+	PublishEnvelope(envelope *events.Envelope, apartmentCode string) error
+}
+
+// StreamEvent is the DynamoDB Streams event received by a Lambda function, only the fields used by the relay are decoded
+type StreamEvent struct {
+	Records []StreamRecord `json:"Records"`
+}
+
+// StreamRecord ...
+type StreamRecord struct {
+	EventID   string `json:"eventID"`
+	EventName string `json:"eventName"`
+	Dynamodb  struct {
+		NewImage map[string]*dynamodb.AttributeValue `json:"NewImage"`
+	} `json:"dynamodb"`
+}
+
+// Relay publishes the pending outbox records and marks them sent
+type Relay struct {
+	// RetryPolicy is applied to throttled and failed DynamoDB calls
+	RetryPolicy retry.Policy
+
+	svc       dynamodbiface.DynamoDBAPI
+	table     string
+	publisher EnvelopePublisher
+}
+
+// NewRelay ...
+func NewRelay(svc dynamodbiface.DynamoDBAPI, table string, publisher EnvelopePublisher) *Relay {
+	return &Relay{RetryPolicy: retry.AWSPolicy(), svc: svc, table: table, publisher: publisher}
+}
+
+// ProcessStreamEvent publishes the records inserted into the outbox table. An error is returned when
+// any record failed, so the stream batch is retried; the image of the stream is always pending, the current
+// status is read before publishing, so records marked sent are skipped on retry. Delivery is at least once:
+// a record published but not marked sent, e.g. after a timeout, is published again, consumers dedupe on the
+// envelope ID.
+func (relay *Relay) ProcessStreamEvent(event *StreamEvent) error {
+	failed := 0
+	for _, streamRecord := range event.Records {
+		if streamRecord.EventName != "INSERT" || streamRecord.Dynamodb.NewImage == nil {
+			continue
+		}
+
+		record := &Record{}
+		err := dynamodbattribute.UnmarshalMap(streamRecord.Dynamodb.NewImage, record)
+		if err != nil {
+			log.Println("Got error unmarshalling outbox record, eventID: "+streamRecord.EventID, err)
+			failed++
+			continue
+		}
+
+		if record.Status != StatusPending {
+			continue
+		}
+
+		err = relay.publish(record)
+		if err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return errors.Errorf("failed to relay %d outbox record(s)", failed)
+	}
+
+	return nil
+}
+
+// PollPending publishes every pending record in creation order and returns the number of published records
+func (relay *Relay) PollPending() (int, error) {
+	records, err := relay.pendingRecords()
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, record := range records {
+		err := relay.publish(record)
+		if err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// Run polls the outbox table until the context is cancelled
+func (relay *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := relay.PollPending()
+		if err != nil {
+			log.Println("Failed to relay outbox records", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (relay *Relay) pendingRecords() ([]*Record, error) {
+	filt := expression.Name("Status").Equal(expression.Value(StatusPending))
+	expr, err := expression.NewBuilder().WithFilter(filt).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &dynamodb.ScanInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+		TableName:                 aws.String(relay.table),
+	}
+	var records []*Record
+	err = relay.RetryPolicy.Do(func() error {
+		// A retried scan starts over
+		records = nil
+		return relay.svc.ScanPages(input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+			for _, item := range page.Items {
+				record := &Record{}
+				if err := dynamodbattribute.UnmarshalMap(item, record); err != nil {
+					log.Println("Got error unmarshalling outbox record", err)
+					continue
+				}
+				records = append(records, record)
+			}
+			return true
+		})
+	})
+	if err != nil {
+		log.Println("Outbox scan failed", err)
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt < records[j].CreatedAt
+	})
+
+	return records, nil
+}
+
+func (relay *Relay) publish(record *Record) error {
+	sent, err := relay.isSent(record)
+	if err != nil {
+		return err
+	}
+	if sent {
+		log.Println("Outbox record already sent: " + record.OutboxID)
+		return nil
+	}
+
+	envelope, err := events.UnmarshalEnvelope(record.Envelope)
+	if err != nil {
+		log.Println("Invalid outbox record: "+record.OutboxID, err)
+		return err
+	}
+
+	err = relay.publisher.PublishEnvelope(envelope, record.ApartmentCode)
+	if err != nil {
+		log.Println("Failed to publish outbox record: "+record.OutboxID, err)
+		return err
+	}
+
+	return relay.markSent(record)
+}
+
+// isSent reads the current status of the record, the stream image and the scan results may be stale
+func (relay *Relay) isSent(record *Record) (bool, error) {
+	result, err := relay.getItem(&dynamodb.GetItemInput{
+		TableName: aws.String(relay.table),
+		Key: map[string]*dynamodb.AttributeValue{
+			"OutboxId": {
+				S: aws.String(record.OutboxID),
+			},
+		},
+		ConsistentRead:       aws.Bool(true),
+		ProjectionExpression: aws.String("#s"),
+		ExpressionAttributeNames: map[string]*string{
+			"#s": aws.String("Status"),
+		},
+	})
+	if err != nil {
+		log.Println("Failed to read outbox record: "+record.OutboxID, err)
+		return false, err
+	}
+
+	status := result.Item["Status"]
+	return status != nil && aws.StringValue(status.S) == StatusSent, nil
+}
+
+func (relay *Relay) markSent(record *Record) error {
+	_, err := relay.updateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String(relay.table),
+		Key: map[string]*dynamodb.AttributeValue{
+			"OutboxId": {
+				S: aws.String(record.OutboxID),
+			},
+		},
+		ExpressionAttributeNames: map[string]*string{
+			"#s": aws.String("Status"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":sent":    {S: aws.String(StatusSent)},
+			":pending": {S: aws.String(StatusPending)},
+			":sentAt":  {S: aws.String(time.Now().UTC().Format(time.RFC3339Nano))},
+		},
+		ConditionExpression: aws.String("#s = :pending"),
+		UpdateExpression:    aws.String("set #s = :sent, SentAt = :sentAt"),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			log.Println("Outbox record already sent: " + record.OutboxID)
+			return nil
+		}
+
+		log.Println("Failed to mark outbox record sent: "+record.OutboxID, err)
+		return err
+	}
+
+	log.Println("Outbox record sent: " + record.OutboxID)
+	return nil
+}
@@ -0,0 +1,38 @@
+package outbox
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func (writer *Writer) transactWriteItems(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	var output *dynamodb.TransactWriteItemsOutput
+	err := writer.RetryPolicy.Do(func() error {
+		var err error
+		output, err = writer.svc.TransactWriteItems(input)
+		return err
+	})
+
+	return output, err
+}
+
+func (relay *Relay) getItem(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	var output *dynamodb.GetItemOutput
+	err := relay.RetryPolicy.Do(func() error {
+		var err error
+		output, err = relay.svc.GetItem(input)
+		return err
+	})
+
+	return output, err
+}
+
+func (relay *Relay) updateItem(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	var output *dynamodb.UpdateItemOutput
+	err := relay.RetryPolicy.Do(func() error {
+		var err error
+		output, err = relay.svc.UpdateItem(input)
+		return err
+	})
+
+	return output, err
+}
@@ -0,0 +1,190 @@
+package pricing
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sylank/lavender-commons-go/money"
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+// DateLayout is the layout of the FromDate and ToDate values of a reservation
+const DateLayout = "2006-01-02"
+
+// ErrUnknownApartment ...
+var ErrUnknownApartment = errors.New("no pricing configured for apartment")
+
+// ErrInvalidDateRange ...
+var ErrInvalidDateRange = errors.New("check-out date must be after check-in date")
+
+// ErrInvalidGuestCount ...
+var ErrInvalidGuestCount = errors.New("invalid guest count")
+
+// MinimumStayError is returned when the stay is shorter than the minimum stay of the apartment or season
+type MinimumStayError struct {
+	Required  int
+	Requested int
+}
+
+func (e *MinimumStayError) Error() string {
+	return fmt.Sprintf("minimum stay is %d nights, requested %d", e.Required, e.Requested)
+}
+
+// NightPrice is the price of a single night of the stay
+type NightPrice struct {
+	Date      time.Time
+	Season    string
+	Weekend   bool
+	BaseRate  money.Money
+	Surcharge money.Money
+	Price     money.Money
+}
+
+// Quote ...
+type Quote struct {
+	ApartmentCode   string
+	FromDate        string
+	ToDate          string
+	Guests          int
+	Nights          []NightPrice
+	Subtotal        money.Money
+	DiscountPercent int64
+	Discount        money.Money
+	Total           money.Money
+	Deposit         money.Money
+	BalanceDue      money.Money
+}
+
+// Calculator ...
+type Calculator struct {
+	properties *props.PricingProperties
+}
+
+// NewCalculator ...
+func NewCalculator(pricingProperties *props.PricingProperties) *Calculator {
+	return &Calculator{properties: pricingProperties}
+}
+
+// Calculate returns the price of a stay, fromDate is the check-in and toDate the check-out date
+func (calculator *Calculator) Calculate(apartmentCode string, fromDate string, toDate string, guests int) (*Quote, error) {
+	pricing, ok := calculator.properties.GetApartmentPricing(apartmentCode)
+	if !ok {
+		return nil, errors.Wrap(ErrUnknownApartment, apartmentCode)
+	}
+
+	checkIn, err := time.Parse(DateLayout, fromDate)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid check-in date: %s", fromDate)
+	}
+
+	checkOut, err := time.Parse(DateLayout, toDate)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid check-out date: %s", toDate)
+	}
+
+	if !checkOut.After(checkIn) {
+		return nil, ErrInvalidDateRange
+	}
+
+	if guests < 1 || (pricing.MaxGuests > 0 && guests > pricing.MaxGuests) {
+		return nil, errors.Wrapf(ErrInvalidGuestCount, "%d guests, maximum %d", guests, pricing.MaxGuests)
+	}
+
+	currency := calculator.properties.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
+	quote := &Quote{
+		ApartmentCode: apartmentCode,
+		FromDate:      fromDate,
+		ToDate:        toDate,
+		Guests:        guests,
+		Subtotal:      money.New(0, currency),
+	}
+
+	minimumStay := pricing.MinimumStay
+	for night := checkIn; night.Before(checkOut); night = night.AddDate(0, 0, 1) {
+		season := findSeason(pricing.Seasons, night)
+
+		baseRate := money.New(pricing.BaseNightlyRate, currency)
+		seasonName := ""
+		if season != nil {
+			baseRate = money.New(season.NightlyRate, currency)
+			seasonName = season.Name
+			if season.MinimumStay > minimumStay {
+				minimumStay = season.MinimumStay
+			}
+		}
+
+		if extraGuests := guests - pricing.IncludedGuests; pricing.IncludedGuests > 0 && extraGuests > 0 {
+			baseRate, _ = baseRate.Add(money.New(pricing.ExtraGuestNightlyRate, currency).Multiply(int64(extraGuests)))
+		}
+
+		weekend := isWeekendNight(night)
+		surcharge := money.New(0, currency)
+		if weekend {
+			surcharge = baseRate.Percent(pricing.WeekendSurchargePercent)
+		}
+
+		price, _ := baseRate.Add(surcharge)
+		quote.Nights = append(quote.Nights, NightPrice{
+			Date:      night,
+			Season:    seasonName,
+			Weekend:   weekend,
+			BaseRate:  baseRate,
+			Surcharge: surcharge,
+			Price:     price,
+		})
+		quote.Subtotal, _ = quote.Subtotal.Add(price)
+	}
+
+	if len(quote.Nights) < minimumStay {
+		return nil, &MinimumStayError{Required: minimumStay, Requested: len(quote.Nights)}
+	}
+
+	quote.DiscountPercent = lengthOfStayDiscount(pricing.LengthOfStayDiscounts, len(quote.Nights))
+	quote.Discount = quote.Subtotal.Percent(quote.DiscountPercent)
+	quote.Total, _ = quote.Subtotal.Sub(quote.Discount)
+	quote.Deposit = quote.Total.Percent(pricing.DepositPercent)
+	quote.BalanceDue, _ = money.BalanceDue(quote.Total, quote.Deposit)
+
+	log.Println(fmt.Sprintf("Price calculated for apartment: %s, nights: %d, total: %s", apartmentCode, len(quote.Nights), quote.Total))
+
+	return quote, nil
+}
+
+// Friday and Saturday nights are considered weekend nights
+func isWeekendNight(night time.Time) bool {
+	return night.Weekday() == time.Friday || night.Weekday() == time.Saturday
+}
+
+func findSeason(seasons []props.SeasonalRate, night time.Time) *props.SeasonalRate {
+	monthDay := night.Format("01-02")
+	for i := range seasons {
+		season := &seasons[i]
+		if season.From <= season.To {
+			if monthDay >= season.From && monthDay <= season.To {
+				return season
+			}
+		} else if monthDay >= season.From || monthDay <= season.To {
+			return season
+		}
+	}
+
+	return nil
+}
+
+func lengthOfStayDiscount(discounts []props.LengthOfStayDiscount, nights int) int64 {
+	var percent int64
+	for _, discount := range discounts {
+		if nights >= discount.MinNights && discount.Percent > percent {
+			percent = discount.Percent
+		}
+	}
+
+	return percent
+}
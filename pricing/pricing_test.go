@@ -0,0 +1,134 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/sylank/lavender-commons-go/money"
+	props "github.com/sylank/lavender-commons-go/properties"
+)
+
+func testProperties() *props.PricingProperties {
+	return &props.PricingProperties{
+		Currency: "HUF",
+		ApartmentPricing: map[string]props.ApartmentPricing{
+			"lavender": {
+				BaseNightlyRate:         10000,
+				IncludedGuests:          2,
+				ExtraGuestNightlyRate:   2000,
+				MaxGuests:               4,
+				WeekendSurchargePercent: 20,
+				MinimumStay:             2,
+				DepositPercent:          30,
+				Seasons: []props.SeasonalRate{
+					{Name: "summer", From: "07-01", To: "08-31", NightlyRate: 15000, MinimumStay: 3},
+					{Name: "winter", From: "12-20", To: "01-10", NightlyRate: 13000},
+				},
+				LengthOfStayDiscounts: []props.LengthOfStayDiscount{
+					{MinNights: 7, Percent: 10},
+					{MinNights: 14, Percent: 15},
+				},
+			},
+		},
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		fromDate        string
+		toDate          string
+		guests          int
+		expectedTotal   int64
+		expectedDeposit int64
+	}{
+		{
+			desc:            "Weekday stay with base rate",
+			fromDate:        "2026-03-02",
+			toDate:          "2026-03-05",
+			guests:          2,
+			expectedTotal:   30000,
+			expectedDeposit: 9000,
+		},
+		{
+			desc:            "Friday and Saturday nights have weekend surcharge",
+			fromDate:        "2026-03-05",
+			toDate:          "2026-03-08",
+			guests:          2,
+			expectedTotal:   34000,
+			expectedDeposit: 10200,
+		},
+		{
+			desc:            "Extra guests are charged per night",
+			fromDate:        "2026-03-02",
+			toDate:          "2026-03-05",
+			guests:          3,
+			expectedTotal:   36000,
+			expectedDeposit: 10800,
+		},
+		{
+			desc:            "Length of stay discount applies from seven nights",
+			fromDate:        "2026-03-02",
+			toDate:          "2026-03-09",
+			guests:          2,
+			expectedTotal:   66600,
+			expectedDeposit: 19980,
+		},
+		{
+			desc:            "Seasonal rate wrapping the new year",
+			fromDate:        "2026-12-30",
+			toDate:          "2027-01-01",
+			guests:          1,
+			expectedTotal:   26000,
+			expectedDeposit: 7800,
+		},
+	}
+	calculator := NewCalculator(testProperties())
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			quote, err := calculator.Calculate("lavender", tC.fromDate, tC.toDate, tC.guests)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if quote.Total != money.New(tC.expectedTotal, "HUF") {
+				t.Errorf("expected total %d, got %s", tC.expectedTotal, quote.Total)
+			}
+
+			if quote.Deposit != money.New(tC.expectedDeposit, "HUF") {
+				t.Errorf("expected deposit %d, got %s", tC.expectedDeposit, quote.Deposit)
+			}
+
+			balanceDue, _ := quote.Total.Sub(quote.Deposit)
+			if quote.BalanceDue != balanceDue {
+				t.Errorf("unexpected balance due: %s", quote.BalanceDue)
+			}
+		})
+	}
+}
+
+func TestCalculateErrors(t *testing.T) {
+	calculator := NewCalculator(testProperties())
+
+	_, err := calculator.Calculate("lavender", "2026-07-01", "2026-07-03", 2)
+	minimumStayError, ok := err.(*MinimumStayError)
+	if !ok || minimumStayError.Required != 3 {
+		t.Errorf("expected seasonal minimum stay error, got %v", err)
+	}
+
+	_, err = calculator.Calculate("unknown", "2026-03-02", "2026-03-05", 2)
+	if errors.Cause(err) != ErrUnknownApartment {
+		t.Errorf("expected unknown apartment error, got %v", err)
+	}
+
+	_, err = calculator.Calculate("lavender", "2026-03-05", "2026-03-05", 2)
+	if errors.Cause(err) != ErrInvalidDateRange {
+		t.Errorf("expected invalid date range error, got %v", err)
+	}
+
+	_, err = calculator.Calculate("lavender", "2026-03-02", "2026-03-05", 5)
+	if errors.Cause(err) != ErrInvalidGuestCount {
+		t.Errorf("expected invalid guest count error, got %v", err)
+	}
+}
@@ -21,6 +21,13 @@ type EmailSecrets struct {
 	Password    string `json:"password"`
 }
 
+// CalendarSecrets holds the Google credentials, either a service account key or an OAuth client with a token
+type CalendarSecrets struct {
+	Credentials json.RawMessage `json:"credentials"`
+	Subject     string          `json:"subject"`
+	Token       json.RawMessage `json:"token"`
+}
+
 // DynamoProperties ...
 type DynamoProperties struct {
 	Region    string               `json:"region"`
@@ -40,6 +47,54 @@ type CalendarProperties struct {
 //CalendarInfo ...
 type CalendarInfo struct {
 	CalendarID string `json:"name"`
+	// TimeZone is the IANA zone of the apartment, e.g. Europe/Budapest
+	TimeZone string `json:"timeZone"`
+	// ICalFeeds are the availability feeds of the apartment on the other channels, e.g. Airbnb and Booking.com
+	ICalFeeds []string `json:"icalFeeds"`
+}
+
+// MessagingProperties ...
+type MessagingProperties struct {
+	Topics map[string]TopicInfo `json:"topics"`
+}
+
+// TopicInfo ...
+type TopicInfo struct {
+	TopicArn string `json:"topicArn"`
+}
+
+// PricingProperties ...
+type PricingProperties struct {
+	Currency         string                      `json:"currency"`
+	ApartmentPricing map[string]ApartmentPricing `json:"apartmentPricing"`
+}
+
+// ApartmentPricing holds the rates of an apartment, amounts are in the minor unit of the currency
+type ApartmentPricing struct {
+	BaseNightlyRate         int64                  `json:"baseNightlyRate"`
+	IncludedGuests          int                    `json:"includedGuests"`
+	ExtraGuestNightlyRate   int64                  `json:"extraGuestNightlyRate"`
+	MaxGuests               int                    `json:"maxGuests"`
+	WeekendSurchargePercent int64                  `json:"weekendSurchargePercent"`
+	MinimumStay             int                    `json:"minimumStay"`
+	DepositPercent          int64                  `json:"depositPercent"`
+	Seasons                 []SeasonalRate         `json:"seasons"`
+	LengthOfStayDiscounts   []LengthOfStayDiscount `json:"lengthOfStayDiscounts"`
+}
+
+// SeasonalRate overrides the base nightly rate between two MM-DD dates (inclusive), the range may wrap the new year
+type SeasonalRate struct {
+	Name        string `json:"name"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	NightlyRate int64  `json:"nightlyRate"`
+	MinimumStay int    `json:"minimumStay"`
+}
+
+// LengthOfStayDiscount ...
+type LengthOfStayDiscount struct {
+	MinNights int   `json:"minNights"`
+	Percent   int64 `json:"percent"`
 }
 
 // ReadSecretProperties ...
@@ -98,6 +153,48 @@ func ReadEmailSecrets(fileName string) (*EmailSecrets, error) {
 	return &obj, nil
 }
 
+// ReadCalendarSecrets ...
+func ReadCalendarSecrets(fileName string) (*CalendarSecrets, error) {
+	data := utils.ReadBytesFromFile(fileName)
+	var obj CalendarSecrets
+	err := json.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		log.Println(fmt.Sprintf("Error while reading file, filename: %s", fileName), err)
+
+		return nil, err
+	}
+
+	return &obj, nil
+}
+
+// ReadMessagingProperties ...
+func ReadMessagingProperties(fileName string) (*MessagingProperties, error) {
+	data := utils.ReadBytesFromFile(fileName)
+	var obj MessagingProperties
+	err := json.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		log.Println(fmt.Sprintf("Error while reading file, filename: %s", fileName), err)
+
+		return nil, err
+	}
+
+	return &obj, nil
+}
+
+// ReadPricingProperties ...
+func ReadPricingProperties(fileName string) (*PricingProperties, error) {
+	data := utils.ReadBytesFromFile(fileName)
+	var obj PricingProperties
+	err := json.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		log.Println(fmt.Sprintf("Error while reading file, filename: %s", fileName), err)
+
+		return nil, err
+	}
+
+	return &obj, nil
+}
+
 // GetTableName ...
 func (properties *DynamoProperties) GetTableName(customTableName string) string {
 	tableName := properties.TableInfo[customTableName].TableName
@@ -110,6 +207,28 @@ func (properties *CalendarProperties) GetCalendarID(calendarName string) string
 	return properties.CalendarInfo[calendarName].CalendarID
 }
 
+// GetTimeZone returns the time zone of the apartment, empty when not configured
+func (properties *CalendarProperties) GetTimeZone(calendarName string) string {
+	return properties.CalendarInfo[calendarName].TimeZone
+}
+
+// GetICalFeeds returns the external iCalendar feeds of the apartment
+func (properties *CalendarProperties) GetICalFeeds(calendarName string) []string {
+	return properties.CalendarInfo[calendarName].ICalFeeds
+}
+
+// GetTopicArn ...
+func (properties *MessagingProperties) GetTopicArn(topicName string) string {
+	return properties.Topics[topicName].TopicArn
+}
+
+// GetApartmentPricing ...
+func (properties *PricingProperties) GetApartmentPricing(apartmentCode string) (ApartmentPricing, bool) {
+	pricing, ok := properties.ApartmentPricing[apartmentCode]
+
+	return pricing, ok
+}
+
 // GetEnvironmentName ...
 func GetEnvironmentName() string {
 	return os.Getenv("environment_name")
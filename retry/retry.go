@@ -0,0 +1,177 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"google.golang.org/api/googleapi"
+)
+
+// Classifier decides whether the error of an attempt is worth retrying
+type Classifier func(err error) bool
+
+// Policy describes how failed calls are retried, the zero value calls the function once
+type Policy struct {
+	// MaxAttempts includes the first call
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the randomized fraction of the backoff between 0 and 1, 1 means full jitter
+	Jitter    float64
+	Retryable Classifier
+}
+
+var awsRetryableCodes = map[string]bool{
+	"ProvisionedThroughputExceededException": true,
+	"ThrottlingException":                    true,
+	"Throttling":                             true,
+	"ThrottledException":                     true,
+	"RequestThrottled":                       true,
+	"RequestThrottledException":              true,
+	"RequestLimitExceeded":                   true,
+	"TooManyRequestsException":               true,
+	"TransactionInProgressException":         true,
+	"KMSThrottlingException":                 true,
+	"InternalServerError":                    true,
+	"InternalFailure":                        true,
+	"InternalError":                          true,
+	"ServiceUnavailable":                     true,
+}
+
+var googleRetryableReasons = map[string]bool{
+	"rateLimitExceeded":     true,
+	"userRateLimitExceeded": true,
+	"backendError":          true,
+}
+
+// NewPolicy returns a policy with exponential backoff starting from 100ms, capped at 5s, with full jitter
+func NewPolicy(maxAttempts int, retryable Classifier) Policy {
+	return Policy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         1,
+		Retryable:      retryable,
+	}
+}
+
+// AWSPolicy is the default policy of the dynamo and messaging packages
+func AWSPolicy() Policy {
+	return NewPolicy(5, IsAWSRetryable)
+}
+
+// GooglePolicy is the default policy of the calendar package
+func GooglePolicy() Policy {
+	return NewPolicy(4, IsGoogleRetryable)
+}
+
+// AWSConfig turns off the retryer of the SDK for the clients wrapped by an AWS policy, otherwise every attempt
+// of the policy is retried by the SDK as well
+func AWSConfig() *aws.Config {
+	return aws.NewConfig().WithMaxRetries(0)
+}
+
+// IsAWSRetryable returns true for throttling, 429 and 5xx errors of the AWS services, and for timeouts and
+// connection resets wrapped in the errors of the SDK
+func IsAWSRetryable(err error) bool {
+	if requestFailure, ok := err.(awserr.RequestFailure); ok {
+		if requestFailure.StatusCode() == 429 || requestFailure.StatusCode() >= 500 {
+			return true
+		}
+	}
+
+	if awsErr, ok := err.(awserr.Error); ok {
+		if awsRetryableCodes[awsErr.Code()] {
+			return true
+		}
+
+		origErr := awsErr.OrigErr()
+		return origErr != nil && (isTimeout(origErr) || isConnectionReset(origErr))
+	}
+
+	return isTimeout(err) || isConnectionReset(err)
+}
+
+// IsGoogleRetryable returns true for 429, 5xx and rate limit errors of the Google APIs
+func IsGoogleRetryable(err error) bool {
+	if apiErr, ok := err.(*googleapi.Error); ok {
+		if apiErr.Code == 429 || apiErr.Code >= 500 {
+			return true
+		}
+
+		for _, item := range apiErr.Errors {
+			if googleRetryableReasons[item.Reason] {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return isTimeout(err)
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func isConnectionReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET)
+}
+
+// Backoff returns the wait before the given retry, the first retry is attempt 1
+func (policy Policy) Backoff(attempt int) time.Duration {
+	multiplier := policy.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	backoff := float64(policy.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if policy.MaxBackoff > 0 && backoff > float64(policy.MaxBackoff) {
+		backoff = float64(policy.MaxBackoff)
+	}
+
+	jitter := math.Max(0, math.Min(1, policy.Jitter))
+	backoff = backoff*(1-jitter) + rand.Float64()*backoff*jitter
+
+	return time.Duration(backoff)
+}
+
+// Do calls fn until it succeeds, returns a non retryable error or the attempts run out
+func (policy Policy) Do(fn func() error) error {
+	return policy.DoWithContext(context.Background(), fn)
+}
+
+// DoWithContext is Do which stops waiting when the context is cancelled
+func (policy Policy) DoWithContext(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= policy.MaxAttempts || policy.Retryable == nil || !policy.Retryable(err) {
+			return err
+		}
+
+		backoff := policy.Backoff(attempt)
+		log.Printf("Retrying after %s, attempt %d of %d: %v", backoff, attempt, policy.MaxAttempts, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package retry
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"google.golang.org/api/googleapi"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifiers(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		err        error
+		classifier Classifier
+		expected   bool
+	}{
+		{
+			desc:       "DynamoDB throughput exceeded",
+			err:        awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "slow down", nil),
+			classifier: IsAWSRetryable,
+			expected:   true,
+		},
+		{
+			desc:       "SQS throttling with 400 status",
+			err:        awserr.NewRequestFailure(awserr.New("ThrottlingException", "rate exceeded", nil), 400, "request-id"),
+			classifier: IsAWSRetryable,
+			expected:   true,
+		},
+		{
+			desc:       "AWS 503",
+			err:        awserr.NewRequestFailure(awserr.New("Unknown", "unavailable", nil), 503, "request-id"),
+			classifier: IsAWSRetryable,
+			expected:   true,
+		},
+		{
+			desc:       "AWS request timeout",
+			err:        awserr.New("RequestError", "send request failed", &net.OpError{Op: "read", Err: timeoutError{}}),
+			classifier: IsAWSRetryable,
+			expected:   true,
+		},
+		{
+			desc:       "AWS connection reset",
+			err:        awserr.New("RequestError", "send request failed", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}),
+			classifier: IsAWSRetryable,
+			expected:   true,
+		},
+		{
+			desc:       "AWS validation error",
+			err:        awserr.New("ValidationException", "invalid", nil),
+			classifier: IsAWSRetryable,
+			expected:   false,
+		},
+		{
+			desc:       "Google 429",
+			err:        &googleapi.Error{Code: 429},
+			classifier: IsGoogleRetryable,
+			expected:   true,
+		},
+		{
+			desc:       "Google 403 rate limit",
+			err:        &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "userRateLimitExceeded"}}},
+			classifier: IsGoogleRetryable,
+			expected:   true,
+		},
+		{
+			desc:       "Google 404",
+			err:        &googleapi.Error{Code: 404},
+			classifier: IsGoogleRetryable,
+			expected:   false,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if tC.classifier(tC.err) != tC.expected {
+				t.Errorf("expected %v for %v", tC.expected, tC.err)
+			}
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	policy := Policy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Multiplier:     2,
+		Retryable:      IsGoogleRetryable,
+	}
+
+	calls := 0
+	err := policy.Do(func() error {
+		calls++
+		if calls < 3 {
+			return &googleapi.Error{Code: 500}
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("expected success on third attempt, calls: %d, err: %v", calls, err)
+	}
+
+	calls = 0
+	err = policy.Do(func() error {
+		calls++
+		return &googleapi.Error{Code: 503}
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("expected failure after 3 attempts, calls: %d", calls)
+	}
+
+	calls = 0
+	err = policy.Do(func() error {
+		calls++
+		return errors.New("not retryable")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("non retryable error should not be retried, calls: %d", calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for i, backoff := range expected {
+		if policy.Backoff(i+1) != backoff {
+			t.Errorf("attempt %d: expected %s, got %s", i+1, backoff, policy.Backoff(i+1))
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := policy.Backoff(2)
+		if backoff < 100*time.Millisecond || backoff > 200*time.Millisecond {
+			t.Fatalf("jittered backoff out of range: %s", backoff)
+		}
+	}
+}